@@ -0,0 +1,381 @@
+// Package bench drives a sound workload against a TikTokAPI and measures
+// throughput, latency percentiles, error rates and allocations.
+//
+//	result := bench.Run(ctx, api, bench.Workload{
+//		SoundIDs:    ids,
+//		Pages:       2,
+//		Concurrency: 8,
+//		Iterations:  5,
+//	})
+//
+// Every sound's info is fetched, followed by Pages item_list pages of its
+// videos. Latencies are recorded per request attempt through the API's
+// Metrics hook and per sound for the whole fetch. The ttscrape bench command
+// runs a workload once per session mode and writes the results as a Report.
+//
+// DecodeBenchmarks compares the map and typed item_list decoders on a single
+// synthetic page, without any network in the way.
+package bench
+
+import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+)
+
+// errTikTokStatus is returned for a sound whose info has a non-zero statusCode
+var errTikTokStatus = errors.New("TikTok returned an error status")
+
+// PageSize is the number of videos requested per item_list page
+const PageSize = 30
+
+// LatencySound is the Result.Latency key for whole-sound fetches; the other
+// keys are endpoint paths such as "/api/music/detail/"
+const LatencySound = "sound"
+
+// Workload describes the requests made by a benchmark run
+type Workload struct {
+	SoundIDs    []string             `json:"sound_ids"`
+	Pages       int                  `json:"pages"`       // item_list pages fetched per sound after its info, 0 for info only
+	Concurrency int                  `json:"concurrency"` // Sounds fetched at once, 1 if 0
+	Iterations  int                  `json:"iterations"`  // Passes over SoundIDs, 1 if 0; ignored when Duration is set
+	Duration    ttscrape_go.Duration `json:"duration"`    // Keep cycling through SoundIDs until this much time has passed
+	Decoder     string               `json:"decoder"`     // How item_list pages are decoded, DecoderMap if empty
+}
+
+// Report is the machine-readable output of a benchmark
+type Report struct {
+	Started   time.Time      `json:"started"`
+	Target    string         `json:"target"` // Base URL the requests were sent to, empty for TikTok
+	GoVersion string         `json:"go_version"`
+	Workload  Workload       `json:"workload"`
+	Modes     []Result       `json:"modes"`
+	Decode    []DecodeResult `json:"decode,omitempty"` // Set when decoders were benchmarked
+}
+
+// Result holds the measurements of a single run
+type Result struct {
+	Mode          string             `json:"mode"`
+	Error         string             `json:"error,omitempty"` // Why the run could not start, e.g. sessions failed
+	SetupMS       float64            `json:"setup_ms"`        // Time to create sessions, set by the caller
+	ElapsedMS     float64            `json:"elapsed_ms"`
+	Sounds        int                `json:"sounds"` // Sound fetches, counting repeats
+	SoundErrors   int                `json:"sound_errors"`
+	Videos        int                `json:"videos"`
+	Requests      int                `json:"requests"` // Request attempts, including retries
+	RequestErrors int                `json:"request_errors"`
+	Retries       int                `json:"retries"`
+	ErrorRate     float64            `json:"error_rate"` // RequestErrors / Requests
+	Outcomes      map[string]int     `json:"outcomes"`   // Request attempts by Outcome constant
+	Throughput    Throughput         `json:"throughput"`
+	Latency       map[string]Latency `json:"latency_ms"`
+	Allocs        Allocs             `json:"allocs"`
+}
+
+// Throughput is the rate of completed work per second of the run
+type Throughput struct {
+	SoundsPerSec   float64 `json:"sounds_per_sec"`
+	RequestsPerSec float64 `json:"requests_per_sec"`
+	VideosPerSec   float64 `json:"videos_per_sec"`
+}
+
+// Latency summarises a set of durations in milliseconds
+type Latency struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+}
+
+// Allocs are the heap allocations made by the process during a run
+type Allocs struct {
+	Mallocs         uint64  `json:"mallocs"`
+	Bytes           uint64  `json:"bytes"`
+	PerRequest      float64 `json:"per_request"`
+	BytesPerRequest float64 `json:"bytes_per_request"`
+}
+
+// Run runs the workload against api, which must already have sessions, and
+// returns its measurements. Responses are never served from the cache. The
+// API's Metrics are replaced for the length of the run and restored after.
+// When ctx is cancelled no new sounds are started and the partial results are
+// returned.
+func Run(ctx context.Context, api *ttscrape_go.TikTokAPI, w Workload) Result {
+	rec := newRecorder()
+	previous := api.SwapMetrics(rec)
+	defer api.SetMetrics(previous)
+
+	concurrency := max(w.Concurrency, 1)
+	ids := make(chan string)
+	var (
+		g           errgroup.Group
+		next        uint64
+		sounds      int64
+		soundErrors int64
+		videos      int64
+	)
+
+	// Start the workers
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	// Failed sounds are counted rather than returned, so no worker stops the others
+	for range concurrency {
+		g.Go(func() error {
+			for id := range ids {
+				session := sessionIndex(api, &next)
+				began := time.Now()
+				n, err := fetchSound(ctx, api, id, session, w.Pages, w.Decoder)
+				rec.observeSound(time.Since(began))
+
+				atomic.AddInt64(&sounds, 1)
+				atomic.AddInt64(&videos, int64(n))
+				if err != nil {
+					atomic.AddInt64(&soundErrors, 1)
+				}
+			}
+			return nil
+		})
+	}
+
+	// Feed sound IDs until the iterations are done or the duration has passed
+	g.Go(func() error {
+		defer close(ids)
+		feed(ctx, ids, w)
+		return nil
+	})
+	g.Wait()
+
+	elapsed := time.Since(start)
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	result := rec.result()
+	result.ElapsedMS = milliseconds(elapsed)
+	result.Sounds = int(sounds)
+	result.SoundErrors = int(soundErrors)
+	result.Videos = int(videos)
+	result.Allocs = Allocs{
+		Mallocs: after.Mallocs - before.Mallocs,
+		Bytes:   after.TotalAlloc - before.TotalAlloc,
+	}
+	if result.Requests > 0 {
+		result.ErrorRate = float64(result.RequestErrors) / float64(result.Requests)
+		result.Allocs.PerRequest = float64(result.Allocs.Mallocs) / float64(result.Requests)
+		result.Allocs.BytesPerRequest = float64(result.Allocs.Bytes) / float64(result.Requests)
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		result.Throughput = Throughput{
+			SoundsPerSec:   float64(result.Sounds) / seconds,
+			RequestsPerSec: float64(result.Requests) / seconds,
+			VideosPerSec:   float64(result.Videos) / seconds,
+		}
+	}
+	return result
+}
+
+// feed sends the workload's sound IDs to ids, stopping early if ctx is cancelled
+func feed(ctx context.Context, ids chan<- string, w Workload) {
+	if len(w.SoundIDs) == 0 {
+		return
+	}
+
+	// A duration overrides the number of iterations: IDs are fed until it has
+	// passed, while sounds already started are left to finish
+	iterations := max(w.Iterations, 1)
+	if w.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(w.Duration))
+		defer cancel()
+		iterations = math.MaxInt
+	}
+
+	for range iterations {
+		for _, id := range w.SoundIDs {
+			select {
+			case ids <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// fetchSound fetches a sound's info and then pages of its videos with the
+// given decoder, returning the number of videos received
+func fetchSound(ctx context.Context, api *ttscrape_go.TikTokAPI, id string, session int, pages int, decoder string) (int, error) {
+	options := []ttscrape_go.RequestOption{
+		ttscrape_go.WithSession(session),
+		ttscrape_go.WithCachePolicy(ttscrape_go.CacheBypass),
+	}
+
+	sound := api.Sound(id)
+	info, err := sound.Info(ctx, options...)
+	if err != nil {
+		return 0, err
+	}
+	if status, _ := info["statusCode"].(float64); status != 0 {
+		return 0, errTikTokStatus
+	}
+	if pages <= 0 {
+		return 0, nil
+	}
+
+	count := 0
+	switch decoder {
+	case DecoderTyped, DecoderTypedRaw:
+		if decoder == DecoderTypedRaw {
+			options = append(options, ttscrape_go.WithRawItems())
+		}
+		items, err := sound.Items(ctx, pages*PageSize, 0, options...)
+		if err != nil {
+			return 0, err
+		}
+		for range items {
+			count++
+		}
+	default:
+		videos, err := sound.Videos(ctx, pages*PageSize, 0, options...)
+		if err != nil {
+			return 0, err
+		}
+		for range videos {
+			count++
+		}
+	}
+	return count, ctx.Err()
+}
+
+// sessionIndex picks the next session in round-robin order
+func sessionIndex(api *ttscrape_go.TikTokAPI, next *uint64) int {
+	indexes := api.SessionIndexes()
+	if len(indexes) == 0 {
+		return 0
+	}
+	return indexes[(atomic.AddUint64(next, 1)-1)%uint64(len(indexes))]
+}
+
+// recorder implements ttscrape_go.Metrics, keeping every latency for percentiles
+type recorder struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	outcomes  map[string]int
+	requests  int
+	errors    int
+	retries   int
+}
+
+// recorder must satisfy the metrics interface of the API
+var _ ttscrape_go.Metrics = (*recorder)(nil)
+
+// newRecorder creates an empty recorder
+func newRecorder() *recorder {
+	return &recorder{
+		latencies: make(map[string][]time.Duration),
+		outcomes:  make(map[string]int),
+	}
+}
+
+// ObserveRequest records a request attempt
+func (r *recorder) ObserveRequest(m ttscrape_go.RequestMetric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests++
+	r.outcomes[m.Outcome]++
+	if m.Outcome != ttscrape_go.OutcomeSuccess && m.Outcome != ttscrape_go.OutcomeCacheHit {
+		r.errors++
+	}
+	r.latencies[m.Endpoint] = append(r.latencies[m.Endpoint], m.Duration)
+}
+
+// ObserveRetry records a retry
+func (r *recorder) ObserveRetry(string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retries++
+}
+
+// SetActiveSessions is not recorded
+func (r *recorder) SetActiveSessions(int) {}
+
+// ObservePaging is not recorded; videos are counted by the workers
+func (r *recorder) ObservePaging(string, int) {}
+
+// observeSound records the time taken to fetch a whole sound
+func (r *recorder) observeSound(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.latencies[LatencySound] = append(r.latencies[LatencySound], d)
+}
+
+// result returns the recorded request counts and latency summaries
+func (r *recorder) result() Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := Result{
+		Requests:      r.requests,
+		RequestErrors: r.errors,
+		Retries:       r.retries,
+		Outcomes:      make(map[string]int, len(r.outcomes)),
+		Latency:       make(map[string]Latency, len(r.latencies)),
+	}
+	for outcome, n := range r.outcomes {
+		result.Outcomes[outcome] = n
+	}
+	for key, durations := range r.latencies {
+		result.Latency[key] = summarize(durations)
+	}
+	return result
+}
+
+// summarize returns the mean, percentiles and maximum of durations
+func summarize(durations []time.Duration) Latency {
+	if len(durations) == 0 {
+		return Latency{}
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	return Latency{
+		Count: len(sorted),
+		Mean:  milliseconds(total / time.Duration(len(sorted))),
+		P50:   milliseconds(percentile(sorted, 0.50)),
+		P95:   milliseconds(percentile(sorted, 0.95)),
+		P99:   milliseconds(percentile(sorted, 0.99)),
+		Max:   milliseconds(sorted[len(sorted)-1]),
+	}
+}
+
+// percentile returns the nearest-rank percentile p (0-1) of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,142 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// Decoders accepted by Workload.Decoder and reported by DecodeBenchmarks
+const (
+	DecoderMap      = "map"       // Sound.Videos: the body is read whole and unmarshalled into maps
+	DecoderTyped    = "typed"     // Sound.Items: the body is streamed into typed Items
+	DecoderTypedRaw = "typed-raw" // Sound.Items with WithRawItems
+)
+
+// decodeBenchTime is how long each decoder is timed for
+const decodeBenchTime = time.Second
+
+// DecodeResult measures decoding one item_list page with a single decoder
+type DecodeResult struct {
+	Decoder       string  `json:"decoder"`
+	Items         int     `json:"items"`
+	PageBytes     int     `json:"page_bytes"`
+	Iterations    int     `json:"iterations"`
+	NsPerPage     int64   `json:"ns_per_page"`
+	AllocsPerPage int64   `json:"allocs_per_page"`
+	BytesPerPage  int64   `json:"bytes_per_page"`
+	MBPerSec      float64 `json:"mb_per_sec"`
+}
+
+// DecodeBenchmarks decodes a synthetic item_list page of items videos with
+// each decoder for about a second and returns the cost per page. The
+// page is the one the ttscrape stand-in serves for a SyntheticFixture.
+func DecodeBenchmarks(items int) ([]DecodeResult, error) {
+	page, err := syntheticPage(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []DecodeResult
+	for _, decoder := range []string{DecoderMap, DecoderTyped, DecoderTypedRaw} {
+		decode := pageDecoder(decoder)
+
+		// Check the decoder once outside the timed loop
+		n, err := decode(page)
+		if err != nil {
+			return nil, fmt.Errorf("%s decoder: %w", decoder, err)
+		}
+		if n != items {
+			return nil, fmt.Errorf("%s decoder: got %d items, want %d", decoder, n, items)
+		}
+
+		iterations, elapsed, mem := timeDecode(decode, page)
+		result := DecodeResult{
+			Decoder:       decoder,
+			Items:         items,
+			PageBytes:     len(page),
+			Iterations:    iterations,
+			NsPerPage:     elapsed.Nanoseconds() / int64(iterations),
+			AllocsPerPage: int64(mem.Mallocs) / int64(iterations),
+			BytesPerPage:  int64(mem.TotalAlloc) / int64(iterations),
+		}
+		if elapsed > 0 {
+			result.MBPerSec = float64(len(page)) * float64(iterations) / 1e6 / elapsed.Seconds()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// timeDecode decodes page in growing batches until decodeBenchTime has passed
+// and returns the number of decodes, the time they took and the memory they
+// allocated, as the differences in Mallocs and TotalAlloc
+func timeDecode(decode func(page []byte) (int, error), page []byte) (int, time.Duration, runtime.MemStats) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	iterations, batch := 0, 1
+	for time.Since(start) < decodeBenchTime {
+		for range batch {
+			decode(page)
+		}
+		iterations += batch
+		batch *= 2
+	}
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return iterations, elapsed, runtime.MemStats{
+		Mallocs:    after.Mallocs - before.Mallocs,
+		TotalAlloc: after.TotalAlloc - before.TotalAlloc,
+	}
+}
+
+// pageDecoder returns a function that decodes a page the way decoder does
+// during paging and returns the number of items
+func pageDecoder(decoder string) func(page []byte) (int, error) {
+	switch decoder {
+	case DecoderTyped, DecoderTypedRaw:
+		// Sound.Items decodes every page into the same ItemListPage
+		var list ttscrape_go.ItemListPage
+		keepRaw := decoder == DecoderTypedRaw
+		return func(page []byte) (int, error) {
+			err := ttscrape_go.DecodeItemList(bytes.NewReader(page), &list, keepRaw)
+			return len(list.Items), err
+		}
+	default:
+		return func(page []byte) (int, error) {
+			body, err := io.ReadAll(bytes.NewReader(page))
+			if err != nil {
+				return 0, err
+			}
+			var result map[string]interface{}
+			if err := json.Unmarshal(body, &result); err != nil {
+				return 0, err
+			}
+			itemList, _ := result["itemList"].([]interface{})
+			return len(itemList), nil
+		}
+	}
+}
+
+// syntheticPage encodes the first item_list page of a synthetic sound
+func syntheticPage(items int) ([]byte, error) {
+	fixture := ttscrapetest.SyntheticFixture("7000000000000000001", items)
+	return json.Marshal(map[string]interface{}{
+		"statusCode": 0,
+		"itemList":   fixture.Videos,
+		"hasMore":    true,
+		"cursor":     strconv.Itoa(items),
+		"extra":      map[string]interface{}{"logid": "20261019000000000000000000000000"},
+	})
+}
@@ -0,0 +1,317 @@
+// Package bulk runs a job over a large list of IDs with a bounded worker pool,
+// appending each result to a JSON Lines file as it completes and recording
+// completed IDs in a checkpoint file, so an interrupted run can be resumed
+// without fetching anything twice.
+//
+//	stats, err := bulk.RunFile(ctx, "sound_ids.csv", bulk.SoundJob(api, 5), bulk.Options{
+//		Workers: 4,
+//		Output:  "results.jsonl",
+//	})
+//
+// Running the same command again after a crash skips every ID listed in the
+// checkpoint and appends the remaining results to the same output file.
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultWorkers is the pool size used when Options.Workers is 0
+const defaultWorkers = 4
+
+// checkpointSuffix is appended to Options.Output when Options.Checkpoint is empty
+const checkpointSuffix = ".checkpoint"
+
+// Job fetches the data for a single ID
+type Job func(ctx context.Context, id string) (interface{}, error)
+
+// Options configures a bulk run
+type Options struct {
+	Workers    int          // Number of concurrent jobs, 4 if 0
+	Output     string       // JSON Lines file results are appended to
+	Checkpoint string       // File completed IDs are appended to, Output + ".checkpoint" if empty
+	SkipFailed bool         // Checkpoint failed IDs too, so a resumed run does not retry them
+	OnResult   func(Result) // Called after each result is written, from a single goroutine
+}
+
+// Result is a single line of the output file
+type Result struct {
+	ID     string `json:"id"`
+	Data   any    `json:"data,omitempty"`
+	Error  string `json:"error,omitempty"`
+	TimeMS int64  `json:"time_ms"`
+
+	cancelled bool // The job failed because the run was cancelled
+}
+
+// Stats summarises a bulk run
+type Stats struct {
+	Total     int // IDs read from the input
+	Skipped   int // IDs already in the checkpoint
+	Succeeded int
+	Failed    int
+	Elapsed   time.Duration
+}
+
+// RunFile runs job over the IDs in path. See ReadIDs for the accepted format.
+func RunFile(ctx context.Context, path string, job Job, opts Options) (Stats, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Stats{}, fmt.Errorf("open ID file: %w", err)
+	}
+	defer file.Close()
+
+	return Run(ctx, file, job, opts)
+}
+
+// Run runs job over the IDs read from r. IDs are streamed, so the input can be
+// arbitrarily large. When ctx is cancelled no new jobs are started, results of
+// in-flight jobs that still succeed are written, and Run returns the context
+// error. Jobs that fail because of the cancellation are neither written nor
+// checkpointed, so a resumed run picks them up.
+func Run(ctx context.Context, r io.Reader, job Job, opts Options) (Stats, error) {
+	if opts.Output == "" {
+		return Stats{}, errors.New("bulk: Options.Output is required")
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.Checkpoint == "" {
+		opts.Checkpoint = opts.Output + checkpointSuffix
+	}
+
+	start := time.Now()
+
+	done, err := LoadCheckpoint(opts.Checkpoint)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	output, err := openAppend(opts.Output)
+	if err != nil {
+		return Stats{}, fmt.Errorf("open output: %w", err)
+	}
+	defer output.Close()
+
+	checkpoint, err := openAppend(opts.Checkpoint)
+	if err != nil {
+		return Stats{}, fmt.Errorf("open checkpoint: %w", err)
+	}
+	defer checkpoint.Close()
+
+	ids := make(chan string)
+	results := make(chan Result, opts.Workers)
+
+	// Start the worker pool
+	var g errgroup.Group
+	for range opts.Workers {
+		g.Go(func() error {
+			for id := range ids {
+				results <- runJob(ctx, job, id)
+			}
+			return nil
+		})
+	}
+
+	// Feed IDs that are not checkpointed yet
+	var stats Stats
+	g.Go(func() error {
+		defer close(ids)
+		err := ReadIDs(r, func(id string) bool {
+			stats.Total++
+			if done[id] {
+				stats.Skipped++
+				return true
+			}
+			select {
+			case ids <- id:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("read IDs: %w", err)
+		}
+		return nil
+	})
+
+	var readErr error
+	go func() {
+		readErr = g.Wait()
+		close(results)
+	}()
+
+	// Write results from this goroutine only, so lines are never interleaved
+	var writeErr error
+	for result := range results {
+		// Jobs cut short by cancellation are left for the resumed run
+		if writeErr != nil || result.cancelled {
+			continue
+		}
+		if writeErr = writeResult(output, checkpoint, result, opts.SkipFailed); writeErr != nil {
+			continue
+		}
+
+		if result.Error != "" {
+			stats.Failed++
+		} else {
+			stats.Succeeded++
+		}
+		if opts.OnResult != nil {
+			opts.OnResult(result)
+		}
+	}
+
+	// The feeder has finished once results is closed, so stats.Total and
+	// readErr are safe to read
+	stats.Elapsed = time.Since(start)
+	if readErr != nil {
+		return stats, readErr
+	}
+	if writeErr != nil {
+		return stats, writeErr
+	}
+	return stats, ctx.Err()
+}
+
+// runJob runs job for a single ID and times it
+func runJob(ctx context.Context, job Job, id string) Result {
+	start := time.Now()
+	data, err := job(ctx, id)
+
+	result := Result{
+		ID:     id,
+		Data:   data,
+		TimeMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Data = nil
+		result.Error = err.Error()
+		result.cancelled = ctx.Err() != nil
+	}
+	return result
+}
+
+// writeResult appends a result to the output and then records its ID in the
+// checkpoint. A crash between the two writes costs at most one refetch.
+func writeResult(output, checkpoint *os.File, result Result, skipFailed bool) error {
+	line, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("encode result %s: %w", result.ID, err)
+	}
+	if _, err := output.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+
+	if result.Error != "" && !skipFailed {
+		return nil
+	}
+	if _, err := checkpoint.WriteString(result.ID + "\n"); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ReadIDs calls fn for every ID in r until fn returns false. The input is
+// either one ID per line or CSV with the ID in the first column. Blank lines,
+// lines starting with '#' and a non-numeric header row are skipped.
+func ReadIDs(r io.Reader, fn func(id string) bool) error {
+	scanner := bufio.NewScanner(r)
+	first := true
+	for scanner.Scan() {
+		id := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(id, ','); i >= 0 {
+			id = strings.TrimSpace(id[:i])
+		}
+		id = strings.Trim(id, `"`)
+
+		header := first && !isNumeric(id)
+		first = false
+		if id == "" || strings.HasPrefix(id, "#") || header {
+			continue
+		}
+
+		if !fn(id) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// LoadCheckpoint returns the IDs recorded in a checkpoint file. A missing
+// file is an empty checkpoint. A trailing partial line left by a crash is ignored.
+func LoadCheckpoint(path string) (map[string]bool, error) {
+	done := make(map[string]bool)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	// The last element is empty for a complete file and partial otherwise
+	for _, id := range lines[:len(lines)-1] {
+		if id = strings.TrimSpace(id); id != "" {
+			done[id] = true
+		}
+	}
+	return done, nil
+}
+
+// openAppend opens a file for appending, terminating a partial last line left
+// by a crash so the next write starts on a fresh line
+func openAppend(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size() == 0 {
+		return file, nil
+	}
+
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if last[0] != '\n' {
+		if _, err := file.WriteString("\n"); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// isNumeric reports whether s is made of digits only
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
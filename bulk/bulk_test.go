@@ -0,0 +1,152 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+func TestReadIDs(t *testing.T) {
+	input := "sound_id,title\n1,a\n\n# comment\n\"2\",b\n3\n"
+
+	var ids []string
+	if err := ReadIDs(strings.NewReader(input), func(id string) bool {
+		ids = append(ids, id)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(ids, " "); got != "1 2 3" {
+		t.Errorf("got IDs %q, want \"1 2 3\"", got)
+	}
+}
+
+func TestRunResume(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Workers: 2, Output: filepath.Join(dir, "results.jsonl")}
+	input := "1\n2\n3\n4\n"
+
+	// The first run fails ID 3, which is written but not checkpointed
+	var mu sync.Mutex
+	calls := map[string]int{}
+	job := func(ctx context.Context, id string) (interface{}, error) {
+		mu.Lock()
+		calls[id]++
+		n := calls[id]
+		mu.Unlock()
+		if id == "3" && n == 1 {
+			return nil, errors.New("temporary failure")
+		}
+		return map[string]string{"id": id}, nil
+	}
+
+	stats, err := Run(context.Background(), strings.NewReader(input), job, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Total != 4 || stats.Succeeded != 3 || stats.Failed != 1 {
+		t.Errorf("first run stats %+v", stats)
+	}
+
+	// The resumed run only retries ID 3
+	stats, err = Run(context.Background(), strings.NewReader(input), job, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Total != 4 || stats.Skipped != 3 || stats.Succeeded != 1 {
+		t.Errorf("resumed run stats %+v", stats)
+	}
+	for id, n := range calls {
+		if want := map[bool]int{true: 2, false: 1}[id == "3"]; n != want {
+			t.Errorf("ID %s run %d times, want %d", id, n, want)
+		}
+	}
+
+	done, err := LoadCheckpoint(opts.Output + checkpointSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 4 {
+		t.Errorf("checkpoint holds %d IDs, want 4", len(done))
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Workers: 1, Output: filepath.Join(dir, "results.jsonl")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := func(ctx context.Context, id string) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if id == "2" {
+			cancel()
+			return nil, ctx.Err()
+		}
+		return id, nil
+	}
+
+	_, err := Run(ctx, strings.NewReader("1\n2\n3\n"), job, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+
+	// Only the job that finished before the cancellation is recorded
+	done, err := LoadCheckpoint(opts.Output + checkpointSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || !done["1"] {
+		t.Errorf("checkpoint holds %v, want only 1", done)
+	}
+}
+
+func TestLoadCheckpointPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	if err := os.WriteFile(path, []byte("1\n2\n3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 2 || done["3"] {
+		t.Errorf("got %v, want 1 and 2 only", done)
+	}
+}
+
+func TestSoundJobPagingError(t *testing.T) {
+	srv := ttscrapetest.NewServer()
+	defer srv.Close()
+	srv.AddSound("1", ttscrapetest.SyntheticFixture("1", 60))
+	srv.SetPageSize(10)
+
+	api, err := srv.NewAPI(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+
+	job := SoundJob(api, 60)
+	if _, err := job(context.Background(), "1"); err != nil {
+		t.Fatalf("job failed: %v", err)
+	}
+
+	// A captcha while paging fails the job instead of returning a partial list
+	srv.SetFault(ttscrapetest.PathMusicItemList, ttscrapetest.Fault{Captcha: true})
+	if _, err := job(context.Background(), "1"); err == nil {
+		t.Fatal("job succeeded after a paging error")
+	}
+
+	if _, err := job(context.Background(), "2"); err == nil {
+		t.Fatal("job succeeded for an unknown sound")
+	}
+}
@@ -0,0 +1,73 @@
+package bulk
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+)
+
+// SoundResult is the data written for each sound by SoundJob
+type SoundResult struct {
+	Info   map[string]interface{}   `json:"info"`
+	Videos []map[string]interface{} `json:"videos,omitempty"`
+}
+
+// SoundJob returns a job that fetches a sound's info and up to videos of the
+// videos using it. Jobs are spread across the API's sessions round-robin. A
+// job whose paging ends early with an error fails, so it is not checkpointed
+// and a resumed run fetches the sound again.
+func SoundJob(api *ttscrape_go.TikTokAPI, videos int) Job {
+	var next uint64
+	return func(ctx context.Context, id string) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var pagingErr error
+		options := []ttscrape_go.RequestOption{
+			ttscrape_go.WithSession(sessionIndex(api, &next)),
+			ttscrape_go.WithPagingError(func(err error) { pagingErr = err }),
+		}
+
+		sound := api.Sound(id)
+		info, err := sound.Info(ctx, options...)
+		if err != nil {
+			return nil, err
+		}
+		if err := ttscrape_go.CheckStatus(info); err != nil {
+			return nil, err
+		}
+
+		result := SoundResult{Info: info}
+		if videos <= 0 {
+			return result, nil
+		}
+
+		items, err := sound.Videos(ctx, videos, 0, options...)
+		if err != nil {
+			return nil, err
+		}
+		for video := range items {
+			result.Videos = append(result.Videos, video)
+		}
+		if pagingErr != nil {
+			return nil, fmt.Errorf("list videos: %w", pagingErr)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	}
+}
+
+// sessionIndex picks the next session in round-robin order
+func sessionIndex(api *ttscrape_go.TikTokAPI, next *uint64) int {
+	indexes := api.SessionIndexes()
+	if len(indexes) == 0 {
+		return 0
+	}
+	return indexes[(atomic.AddUint64(next, 1)-1)%uint64(len(indexes))]
+}
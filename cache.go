@@ -0,0 +1,347 @@
+package ttscrape_go
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTLs are the per-endpoint TTLs used when CacheOptions.TTLs is nil.
+// Paginated and feed endpoints are left out since their responses change between calls.
+var DefaultCacheTTLs = map[string]time.Duration{
+	"/api/music/detail/":     10 * time.Minute,
+	"/api/item/detail/":      10 * time.Minute,
+	"/api/user/detail/":      10 * time.Minute,
+	"/api/challenge/detail/": 10 * time.Minute,
+	"/api/mix/detail/":       10 * time.Minute,
+}
+
+// defaultCacheEntries is the in-memory capacity used when CacheOptions.MaxEntries is 0
+const defaultCacheEntries = 1000
+
+// cacheIgnoredParams are left out of cache keys because they identify the session, not the request
+var cacheIgnoredParams = map[string]bool{
+	"msToken":   true,
+	"device_id": true,
+}
+
+// CacheOptions configures a ResponseCache
+type CacheOptions struct {
+	MaxEntries int                      // Capacity of the in-memory LRU tier, 1000 if 0
+	TTLs       map[string]time.Duration // TTL per endpoint path, DefaultCacheTTLs if nil
+	DefaultTTL time.Duration            // TTL for endpoints missing from TTLs, 0 to not cache them
+	Dir        string                   // Directory for the disk tier, empty to keep the cache in memory only
+}
+
+// CacheStats counts cache activity
+type CacheStats struct {
+	Hits       int64 // Lookups served from either tier
+	MemoryHits int64
+	DiskHits   int64
+	Misses     int64
+	Stores     int64
+	Evictions  int64 // Entries dropped from the memory tier to make room
+}
+
+// ResponseCache caches API responses in an in-memory LRU tier backed by an
+// optional disk tier. It is safe for concurrent use.
+type ResponseCache struct {
+	opts CacheOptions
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Front is most recently used
+	stats   CacheStats
+}
+
+// cacheEntry is a cached response body. Body is kept as raw bytes since not
+// every endpoint answers with JSON.
+type cacheEntry struct {
+	Key     string    `json:"key"`
+	Expires time.Time `json:"expires"`
+	Body    []byte    `json:"body"`
+}
+
+// NewResponseCache creates a response cache, creating the disk directory if
+// needed and pruning the entries in it that have expired
+func NewResponseCache(opts CacheOptions) (*ResponseCache, error) {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = defaultCacheEntries
+	}
+	if opts.TTLs == nil {
+		opts.TTLs = DefaultCacheTTLs
+	}
+	if opts.Dir != "" {
+		if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+			return nil, fmt.Errorf("create cache directory: %w", err)
+		}
+	}
+
+	c := &ResponseCache{
+		opts:    opts,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+	if err := c.Prune(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetCache sets the cache used for API responses, or disables caching if nil
+func (api *TikTokAPI) SetCache(cache *ResponseCache) {
+	api.Cache = cache
+}
+
+// Stats returns a snapshot of the cache counters
+func (c *ResponseCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+// Clear removes every entry from both tiers
+func (c *ResponseCache) Clear() error {
+	c.mu.Lock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.mu.Unlock()
+
+	return c.removeDisk(func(string) bool { return true })
+}
+
+// Prune removes expired entries from both tiers. Expired entries are never
+// served, but without pruning their files stay in the disk tier until
+// they are looked up again.
+func (c *ResponseCache) Prune() error {
+	now := time.Now()
+
+	c.mu.Lock()
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if entry := elem.Value.(*cacheEntry); !now.Before(entry.Expires) {
+			c.lru.Remove(elem)
+			delete(c.entries, entry.Key)
+		}
+		elem = next
+	}
+	c.mu.Unlock()
+
+	return c.removeDisk(func(path string) bool {
+		entry, ok := readEntry(path)
+		return !ok || !now.Before(entry.Expires)
+	})
+}
+
+// removeDisk removes the disk tier files for which remove returns true
+func (c *ResponseCache) removeDisk(remove func(path string) bool) error {
+	if c.opts.Dir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(c.opts.Dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !remove(f) {
+			continue
+		}
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove cache entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// policy returns the cache key and TTL for a call. A zero TTL means the call is not cached.
+func (c *ResponseCache) policy(call apiCall) (string, time.Duration) {
+	if c == nil {
+		return "", 0
+	}
+
+	// Key on the endpoint path so absolute URLs and paths share entries
+	endpoint := call.Endpoint
+	if parsedURL, err := url.Parse(call.Endpoint); err == nil {
+		endpoint = parsedURL.Path
+	}
+
+	ttl, ok := c.opts.TTLs[endpoint]
+	if !ok {
+		ttl = c.opts.DefaultTTL
+	}
+	if ttl <= 0 {
+		return "", 0
+	}
+
+	// Add the significant params in a stable order
+	keys := make([]string, 0, len(call.Params))
+	for k := range call.Params {
+		if !cacheIgnoredParams[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	// Escape each part so separators inside a value cannot forge another key
+	var b strings.Builder
+	b.WriteString(url.PathEscape(endpoint))
+	for _, k := range keys {
+		b.WriteString("&")
+		b.WriteString(url.QueryEscape(k))
+		b.WriteString("=")
+		b.WriteString(url.QueryEscape(call.Params[k]))
+	}
+
+	return b.String(), ttl
+}
+
+// get returns the cached body for key, checking memory before disk
+func (c *ResponseCache) get(key string) ([]byte, bool) {
+	now := time.Now()
+
+	// Check the memory tier
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if now.Before(entry.Expires) {
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			c.stats.MemoryHits++
+			c.mu.Unlock()
+			return entry.Body, true
+		}
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	// Check the disk tier without holding c.mu, so slow disks only hold up
+	// their own lookups
+	entry, ok := c.readDisk(key)
+	if ok && !now.Before(entry.Expires) {
+		os.Remove(c.diskPath(key))
+		ok = false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.addMemory(entry)
+	c.stats.Hits++
+	c.stats.DiskHits++
+	return entry.Body, true
+}
+
+// put stores a body in both tiers
+func (c *ResponseCache) put(key string, body []byte, ttl time.Duration) {
+	entry := &cacheEntry{
+		Key:     key,
+		Expires: time.Now().Add(ttl),
+		Body:    body,
+	}
+
+	c.mu.Lock()
+	c.addMemory(entry)
+	c.stats.Stores++
+	c.mu.Unlock()
+
+	c.writeDisk(entry)
+}
+
+// addMemory adds an entry to the memory tier, evicting the least recently used if full.
+// The caller must hold c.mu.
+func (c *ResponseCache) addMemory(entry *cacheEntry) {
+	if elem, ok := c.entries[entry.Key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[entry.Key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.opts.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).Key)
+		c.stats.Evictions++
+	}
+}
+
+// diskPath returns the file holding key in the disk tier
+func (c *ResponseCache) diskPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.opts.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readDisk loads an entry from the disk tier
+func (c *ResponseCache) readDisk(key string) (*cacheEntry, bool) {
+	if c.opts.Dir == "" {
+		return nil, false
+	}
+
+	entry, ok := readEntry(c.diskPath(key))
+	if !ok || entry.Key != key {
+		return nil, false
+	}
+	return entry, true
+}
+
+// readEntry decodes the disk tier file at path
+func readEntry(path string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// writeDisk stores an entry in the disk tier. The disk tier is best effort, so
+// failures only cost a future cache miss.
+func (c *ResponseCache) writeDisk(entry *cacheEntry) {
+	if c.opts.Dir == "" {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	// Write to a temporary file first so readers never see a partial entry.
+	// Each writer gets its own file since puts of one key may race.
+	path := c.diskPath(entry.Key)
+	tmp, err := os.CreateTemp(c.opts.Dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,282 @@
+package ttscrape_go_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// newCache returns a response cache, failing the test if it cannot be created
+func newCache(t *testing.T, opts ttscrape_go.CacheOptions) *ttscrape_go.ResponseCache {
+	t.Helper()
+	cache, err := ttscrape_go.NewResponseCache(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+func TestCacheHit(t *testing.T) {
+	api, srv := newStandin(t)
+	cache := newCache(t, ttscrape_go.CacheOptions{})
+	api.SetCache(cache)
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := api.Sound("1").Info(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := srv.Requests(ttscrapetest.PathMusicDetail); n != 1 {
+		t.Errorf("%d music/detail requests, want 1", n)
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.MemoryHits != 2 || stats.Misses != 1 || stats.Stores != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	// Paging endpoints are not cached by default
+	for range 2 {
+		videos, err := api.Sound("1").Videos(ctx, 5, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range videos {
+		}
+	}
+	if n := srv.Requests(ttscrapetest.PathMusicItemList); n != 2 {
+		t.Errorf("%d item_list requests, want 2", n)
+	}
+}
+
+func TestCachePolicies(t *testing.T) {
+	api, srv := newStandin(t)
+	cache := newCache(t, ttscrape_go.CacheOptions{})
+	api.SetCache(cache)
+	ctx := context.Background()
+	sound := api.Sound("1")
+
+	// Bypass neither reads nor writes the cache
+	if _, err := sound.Info(ctx, ttscrape_go.WithCachePolicy(ttscrape_go.CacheBypass)); err != nil {
+		t.Fatal(err)
+	}
+	if stats := cache.Stats(); stats.Stores != 0 || stats.Misses != 0 {
+		t.Errorf("CacheBypass touched the cache: %+v", stats)
+	}
+
+	// Refresh skips the lookup but stores the response
+	for range 2 {
+		if _, err := sound.Info(ctx, ttscrape_go.WithCachePolicy(ttscrape_go.CacheRefresh)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := srv.Requests(ttscrapetest.PathMusicDetail); n != 3 {
+		t.Errorf("%d music/detail requests, want 3", n)
+	}
+	if stats := cache.Stats(); stats.Stores != 2 || stats.Hits != 0 {
+		t.Errorf("CacheRefresh stats = %+v", stats)
+	}
+
+	// The refreshed response is then served by default
+	if _, err := sound.Info(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Requests(ttscrapetest.PathMusicDetail); n != 3 {
+		t.Errorf("default policy after a refresh made a request: %d in total", n)
+	}
+}
+
+func TestCacheDisk(t *testing.T) {
+	api, srv := newStandin(t)
+	dir := t.TempDir()
+	api.SetCache(newCache(t, ttscrape_go.CacheOptions{Dir: dir}))
+	ctx := context.Background()
+
+	if _, err := api.Sound("1").Info(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new cache over the same directory starts with an empty memory tier
+	cache := newCache(t, ttscrape_go.CacheOptions{Dir: dir})
+	api.SetCache(cache)
+	info, err := api.Sound("1").Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info["musicInfo"] == nil {
+		t.Errorf("disk hit returned %v", info)
+	}
+	if n := srv.Requests(ttscrapetest.PathMusicDetail); n != 1 {
+		t.Errorf("%d music/detail requests, want 1", n)
+	}
+	if stats := cache.Stats(); stats.DiskHits != 1 {
+		t.Errorf("stats = %+v, want a disk hit", stats)
+	}
+
+	// Clear empties both tiers
+	if err := cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.Sound("1").Info(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Requests(ttscrapetest.PathMusicDetail); n != 2 {
+		t.Errorf("%d music/detail requests after Clear, want 2", n)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	api, srv := newStandin(t)
+	api.SetCache(newCache(t, ttscrape_go.CacheOptions{
+		TTLs: map[string]time.Duration{ttscrapetest.PathMusicDetail: 50 * time.Millisecond},
+	}))
+	ctx := context.Background()
+
+	if _, err := api.Sound("1").Info(ctx); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, err := api.Sound("1").Info(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Requests(ttscrapetest.PathMusicDetail); n != 2 {
+		t.Errorf("%d music/detail requests, want 2 after the entry expired", n)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.AddSound("2", ttscrapetest.SyntheticFixture("2", 1))
+	cache := newCache(t, ttscrape_go.CacheOptions{MaxEntries: 1})
+	api.SetCache(cache)
+	ctx := context.Background()
+
+	for _, id := range []string{"1", "2", "1"} {
+		if _, err := api.Sound(id).Info(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := srv.Requests(ttscrapetest.PathMusicDetail); n != 3 {
+		t.Errorf("%d music/detail requests, want 3", n)
+	}
+	if stats := cache.Stats(); stats.Evictions != 2 {
+		t.Errorf("stats = %+v, want 2 evictions", stats)
+	}
+}
+
+func TestCacheDiskPage(t *testing.T) {
+	api, srv := newStandin(t)
+	dir := t.TempDir()
+	opts := ttscrape_go.CacheOptions{DefaultTTL: time.Minute, Dir: dir}
+	api.SetCache(newCache(t, opts))
+	ctx := context.Background()
+
+	// Server-rendered pages are HTML, and are served from the disk tier too
+	if _, err := api.FetchPageData(ctx, "/music/sound-1", "webapp.music-detail", 0); err != nil {
+		t.Fatal(err)
+	}
+	cache := newCache(t, opts)
+	api.SetCache(cache)
+	scope, err := api.FetchPageData(ctx, "/music/sound-1", "webapp.music-detail", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scope["musicInfo"] == nil {
+		t.Errorf("disk hit returned %v", scope)
+	}
+	if n := srv.Requests("/music/sound-1"); n != 1 {
+		t.Errorf("%d page requests, want 1", n)
+	}
+	if stats := cache.Stats(); stats.DiskHits != 1 {
+		t.Errorf("stats = %+v, want a disk hit", stats)
+	}
+}
+
+func TestCacheKeyEscaping(t *testing.T) {
+	// The stand-in echoes the query it was sent
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"statusCode": 0, "musicId": r.URL.Query().Get("musicId")})
+	}))
+	defer srv.Close()
+
+	api := ttscrape_go.NewTikTokAPI(0)
+	api.SetBrowserFree(true)
+	api.SetBaseURL(srv.URL)
+	if err := api.CreateSessions(context.Background(), 1, []string{"token"}, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+	api.SetCache(newCache(t, ttscrape_go.CacheOptions{}))
+
+	// A separator inside a value must not make the call look like another one
+	ctx := context.Background()
+	for _, params := range []map[string]string{
+		{"musicId": "1&x=2"},
+		{"musicId": "1", "x": "2"},
+	} {
+		resp, err := api.MakeRequestContext(ctx, ttscrapetest.PathMusicDetail, params, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp["musicId"] != params["musicId"] {
+			t.Errorf("params %v got the response for musicId %v", params, resp["musicId"])
+		}
+	}
+	if requests != 2 {
+		t.Errorf("%d requests, want 2", requests)
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	api, _ := newStandin(t)
+	dir := t.TempDir()
+	opts := ttscrape_go.CacheOptions{
+		TTLs: map[string]time.Duration{ttscrapetest.PathMusicDetail: 50 * time.Millisecond},
+		Dir:  dir,
+	}
+	cache := newCache(t, opts)
+	api.SetCache(cache)
+
+	if _, err := api.Sound("1").Info(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	files := func() int {
+		t.Helper()
+		matches, err := filepath.Glob(filepath.Join(dir, "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(matches)
+	}
+	if n := files(); n != 1 {
+		t.Fatalf("%d files in the disk tier, want 1", n)
+	}
+
+	// Unexpired entries are kept, and expired ones removed when the cache is
+	// opened or pruned
+	if err := cache.Prune(); err != nil {
+		t.Fatal(err)
+	}
+	newCache(t, opts)
+	if n := files(); n != 1 {
+		t.Errorf("%d files after pruning before expiry, want 1", n)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(dir, "corrupt.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	newCache(t, opts)
+	if n := files(); n != 0 {
+		t.Errorf("%d files after opening the cache past expiry, want 0", n)
+	}
+}
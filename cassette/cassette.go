@@ -0,0 +1,333 @@
+// Package cassette provides an http.RoundTripper that records TikTok traffic
+// to disk and replays it deterministically, so parsing bugs seen in production
+// can be reproduced without hitting TikTok.
+//
+// Install it with TikTokAPI.SetHTTPClient:
+//
+//	rec, err := cassette.New("testdata/sound.json", cassette.ModeRecord, nil)
+//	api.SetHTTPClient(&http.Client{Transport: rec})
+//	...
+//	rec.Save()
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode selects whether a Recorder records or replays
+type Mode int
+
+const (
+	// ModeReplay serves every request from the cassette and never touches the network
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the network and records them
+	ModeRecord
+	// ModeReplayOrRecord replays known requests and records the rest
+	ModeReplayOrRecord
+)
+
+// redacted replaces secret values in recorded requests and responses
+const redacted = "REDACTED"
+
+// ErrNoInteraction is returned in replay mode when no recorded interaction matches a request
+var ErrNoInteraction = errors.New("no recorded interaction matches request")
+
+// RedactedParams are query parameters whose values are never written to disk
+var RedactedParams = []string{"msToken", "device_id"}
+
+// IgnoredParams are query parameters left out when matching requests, because
+// they change between sessions rather than describing what was asked for
+var IgnoredParams = []string{
+	"msToken", "device_id", "X-Bogus", "X-Gnarly", "_signature", "verifyFp",
+	"browser_version", "app_language", "browser_language", "language",
+	"webcast_language", "history_len", "tz_name",
+}
+
+// redactedHeaders are headers whose values are never written to disk
+var redactedHeaders = []string{"Cookie", "Set-Cookie"}
+
+// Interaction is a recorded request/response pair
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the recorded part of a request
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+}
+
+// Response is the recorded part of a response
+type Response struct {
+	StatusCode   int         `json:"status_code"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         string      `json:"body"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" for non UTF-8 bodies
+}
+
+// file is the on-disk cassette layout
+type file struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records and replays interactions
+type Recorder struct {
+	path string
+	mode Mode
+	next http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     map[string]int // Times each match key was replayed
+}
+
+// New returns a Recorder backed by the cassette file at path. Existing
+// interactions are loaded in the replay modes; a missing file is only an
+// error in ModeReplay. next is used to reach the network and defaults to
+// http.DefaultTransport.
+func New(path string, mode Mode, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	r := &Recorder{
+		path:     path,
+		mode:     mode,
+		next:     next,
+		replayed: make(map[string]int),
+	}
+
+	if mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && mode == ModeReplayOrRecord {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("decode cassette: %w", err)
+	}
+	r.interactions = f.Interactions
+
+	return r, nil
+}
+
+// RoundTrip replays a recorded response or records a new one, depending on the mode
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode != ModeRecord {
+		if interaction, ok := r.match(req); ok {
+			return interaction.Response.toHTTP(req)
+		}
+		if r.mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, matchKey(req.Method, req.URL))
+		}
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Media files are streamed through rather than kept in the cassette
+	contentType := resp.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "video/") || strings.HasPrefix(contentType, "audio/") {
+		return resp, nil
+	}
+
+	// Read the body so it can be both recorded and returned
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     redactURL(req.URL),
+			Headers: redactHeaders(req.Header),
+		},
+		Response: newResponse(resp, body),
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Save writes all interactions to the cassette file
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(file{Interactions: r.interactions}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encode cassette: %w", err)
+	}
+
+	if err := os.WriteFile(r.path, data, 0644); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	return nil
+}
+
+// Interactions returns a copy of the recorded interactions
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interactions := make([]Interaction, len(r.interactions))
+	copy(interactions, r.interactions)
+	return interactions
+}
+
+// match finds the interaction to replay for req. Identical requests replay
+// their recordings in order, repeating the last one once they run out.
+func (r *Recorder) match(req *http.Request) (Interaction, bool) {
+	key := matchKey(req.Method, req.URL)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var candidates []int
+	for i, interaction := range r.interactions {
+		recordedURL, err := url.Parse(interaction.Request.URL)
+		if err != nil {
+			continue
+		}
+		if matchKey(interaction.Request.Method, recordedURL) == key {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return Interaction{}, false
+	}
+
+	n := r.replayed[key]
+	r.replayed[key]++
+	if n >= len(candidates) {
+		n = len(candidates) - 1
+	}
+	return r.interactions[candidates[n]], true
+}
+
+// matchKey identifies a request by method, path and stable query parameters.
+// The host is ignored so cassettes replay against any base URL.
+func matchKey(method string, u *url.URL) string {
+	query := u.Query()
+	for _, param := range IgnoredParams {
+		query.Del(param)
+	}
+
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(method)
+	b.WriteString(" ")
+	b.WriteString(u.Path)
+	for i, k := range keys {
+		if i == 0 {
+			b.WriteString("?")
+		} else {
+			b.WriteString("&")
+		}
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(strings.Join(query[k], ","))
+	}
+	return b.String()
+}
+
+// redactURL returns u as a string with secret parameter values replaced
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	query := redactedURL.Query()
+	for _, param := range RedactedParams {
+		if query.Has(param) {
+			query.Set(param, redacted)
+		}
+	}
+	redactedURL.RawQuery = query.Encode()
+	return redactedURL.String()
+}
+
+// redactHeaders returns a copy of h with secret header values replaced
+func redactHeaders(h http.Header) http.Header {
+	headers := h.Clone()
+	for _, name := range redactedHeaders {
+		if headers.Get(name) != "" {
+			headers.Set(name, redacted)
+		}
+	}
+	return headers
+}
+
+// newResponse records a response and its body
+func newResponse(resp *http.Response, body []byte) Response {
+	recorded := Response{
+		StatusCode: resp.StatusCode,
+		Headers:    redactHeaders(resp.Header),
+	}
+	if utf8.Valid(body) {
+		recorded.Body = string(body)
+	} else {
+		recorded.Body = base64.StdEncoding.EncodeToString(body)
+		recorded.BodyEncoding = "base64"
+	}
+	return recorded
+}
+
+// toHTTP builds the replayed response for req
+func (r Response) toHTTP(req *http.Request) (*http.Response, error) {
+	body := []byte(r.Body)
+	if r.BodyEncoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("decode recorded body: %w", err)
+		}
+		body = decoded
+	}
+
+	headers := r.Headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	headers.Del("Set-Cookie")
+	headers.Del("Content-Encoding")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        headers,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
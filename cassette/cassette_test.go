@@ -0,0 +1,150 @@
+package cassette_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fortindustries/ttscrape-go/cassette"
+)
+
+// failTransport fails every request, proving replays never reach the network
+type failTransport struct{}
+
+func (failTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("network used during replay")
+}
+
+// newUpstream starts a server answering each request with its sequence number,
+// except /binary and /video.mp4
+func newUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	var n atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/binary":
+			w.Write([]byte{0xff, 0xfe, 0x00, 0x01})
+		case "/video.mp4":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write([]byte("mp4 data"))
+		default:
+			http.SetCookie(w, &http.Cookie{Name: "ttwid", Value: "server-secret"})
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"n":%d}`, n.Add(1))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// get sends a GET through rt and returns the status code and body
+func get(t *testing.T, rt http.RoundTripper, rawURL string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Cookie", "msToken=client-secret")
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", rawURL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestRecordReplay(t *testing.T) {
+	upstream := newUpstream(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := cassette.New(path, cassette.ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(t, rec, upstream.URL+"/api/music/detail/?musicId=1&msToken=token-secret")
+	get(t, rec, upstream.URL+"/api/music/detail/?musicId=1&msToken=token-secret")
+	get(t, rec, upstream.URL+"/binary")
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Secrets are never written to disk
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"token-secret", "client-secret", "server-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %s", secret)
+		}
+	}
+
+	// Replays ignore the host and session parameters, and identical requests
+	// replay in order, repeating the last recording
+	replay, err := cassette.New(path, cassette.ModeReplay, failTransport{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{`{"n":1}`, `{"n":2}`, `{"n":2}`} {
+		status, body := get(t, replay, "https://www.tiktok.com/api/music/detail/?msToken=other&musicId=1&X-Bogus=x")
+		if status != http.StatusOK || body != want {
+			t.Errorf("replay %d = %d %s, want 200 %s", i, status, body, want)
+		}
+	}
+	if _, body := get(t, replay, "https://www.tiktok.com/binary"); body != "\xff\xfe\x00\x01" {
+		t.Errorf("binary body replayed as %q", body)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://www.tiktok.com/api/music/detail/?musicId=2", nil)
+	if _, err := replay.RoundTrip(req); !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("unrecorded request returned %v, want ErrNoInteraction", err)
+	}
+}
+
+func TestReplayOrRecord(t *testing.T) {
+	upstream := newUpstream(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	if _, err := cassette.New(path, cassette.ModeReplay, nil); err == nil {
+		t.Error("ModeReplay accepted a missing cassette")
+	}
+
+	rec, err := cassette.New(path, cassette.ModeReplayOrRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(t, rec, upstream.URL+"/api/item/detail/?itemId=1")
+	get(t, rec, upstream.URL+"/video.mp4")
+	if n := len(rec.Interactions()); n != 1 {
+		t.Errorf("recorded %d interactions, want 1 without the video", n)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Known requests are replayed and new ones recorded
+	rec, err = cassette.New(path, cassette.ModeReplayOrRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, body := get(t, rec, upstream.URL+"/api/item/detail/?itemId=1"); body != `{"n":1}` {
+		t.Errorf("known request returned %s, want the recording", body)
+	}
+	if _, body := get(t, rec, upstream.URL+"/api/item/detail/?itemId=2"); body != `{"n":2}` {
+		t.Errorf("new request returned %s", body)
+	}
+	if n := len(rec.Interactions()); n != 2 {
+		t.Errorf("%d interactions after recording a new request, want 2", n)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/bench"
+	"github.com/fortindustries/ttscrape-go/bulk"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// benchModes maps -modes names to the session settings they benchmark
+var benchModes = map[string]func(cfg *ttscrape_go.Config){
+	"browser-free": func(cfg *ttscrape_go.Config) { cfg.BrowserFree, cfg.Headless = true, true },
+	"headless":     func(cfg *ttscrape_go.Config) { cfg.BrowserFree, cfg.Headless = false, true },
+	"regular":      func(cfg *ttscrape_go.Config) { cfg.BrowserFree, cfg.Headless = false, false },
+}
+
+// localSounds is the number of sounds served by -local when no IDs are given
+const localSounds = 10
+
+// runBench implements "bench"
+func runBench(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	sounds := fs.String("sounds", "", "Comma-separated sound IDs to fetch")
+	soundFile := fs.String("sound-file", "", "File of sound IDs to fetch, one per line")
+	pages := fs.Int("pages", 1, "Pages of videos fetched per sound after its info, 0 for info only")
+	concurrency := fs.Int("concurrency", 4, "Number of sounds fetched at once")
+	iterations := fs.Int("iterations", 1, "Passes over the sound IDs")
+	duration := fs.Duration("duration", 0, "Keep fetching sounds for this long instead of a number of -iterations")
+	modes := fs.String("modes", "browser-free", "Comma-separated session modes to run: browser-free, headless, regular; empty for -decode only")
+	decoder := fs.String("decoder", bench.DecoderMap, "How item_list pages are decoded: map (Sound.Videos), typed or typed-raw (Sound.Items)")
+	decode := fs.Bool("decode", false, "Also benchmark decoding a single item_list page with each decoder")
+	decodeItems := fs.Int("decode-items", bench.PageSize, "Videos on the page decoded by -decode")
+	local := fs.Bool("local", false, "Run against a local stand-in server instead of -base-url")
+	localVideos := fs.Int("local-videos", 300, "Videos served per sound by the -local stand-in")
+	localDelay := fs.Duration("local-delay", 0, "Delay added to every -local stand-in response")
+	out := fs.String("o", "", "Also write the JSON report to this file")
+
+	// Benchmarks are bounded by their workload, so there is no overall timeout unless one is given
+	cf.timeout = 0
+	fs.Lookup("timeout").DefValue = "0s"
+
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if *concurrency < 1 || *iterations < 1 || *pages < 0 || *decodeItems < 1 {
+		return &usageError{msg: "-concurrency, -iterations and -decode-items must be at least 1 and -pages at least 0"}
+	}
+	switch *decoder {
+	case bench.DecoderMap, bench.DecoderTyped, bench.DecoderTypedRaw:
+	default:
+		return &usageError{msg: fmt.Sprintf("unknown decoder %q", *decoder)}
+	}
+
+	modeNames := splitList(*modes)
+	for _, name := range modeNames {
+		if _, ok := benchModes[name]; !ok {
+			return &usageError{msg: fmt.Sprintf("unknown mode %q", name)}
+		}
+	}
+	if len(modeNames) == 0 && !*decode {
+		return &usageError{msg: "nothing to run: set -modes or -decode"}
+	}
+
+	// Resolve the workload
+	workload := bench.Workload{
+		SoundIDs:    splitList(*sounds),
+		Pages:       *pages,
+		Concurrency: *concurrency,
+		Iterations:  *iterations,
+		Duration:    ttscrape_go.Duration(*duration),
+		Decoder:     *decoder,
+	}
+	if *soundFile != "" {
+		ids, err := readIDFile(*soundFile)
+		if err != nil {
+			return err
+		}
+		workload.SoundIDs = append(workload.SoundIDs, ids...)
+	}
+	if len(workload.SoundIDs) == 0 && *local {
+		for i := range localSounds {
+			workload.SoundIDs = append(workload.SoundIDs, strconv.Itoa(7000000000000000001+i))
+		}
+	}
+	if len(workload.SoundIDs) == 0 && len(modeNames) > 0 {
+		return &usageError{msg: "no sound IDs: set -sounds or -sound-file"}
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	// Start the stand-in and send every request to it
+	if *local && len(modeNames) > 0 {
+		url, stopStandin, err := startStandin(workload.SoundIDs, *localVideos, *localDelay)
+		if err != nil {
+			return err
+		}
+		defer stopStandin()
+		fs.Set("base-url", url)
+		if cf.msTokens == "" {
+			cf.msTokens = "ttscrape-bench"
+		}
+	}
+
+	report := bench.Report{
+		Started:   time.Now().UTC(),
+		GoVersion: runtime.Version(),
+		Workload:  workload,
+	}
+
+	// Decode benchmarks run first, while nothing else is allocating
+	if *decode {
+		results, err := bench.DecodeBenchmarks(*decodeItems)
+		if err != nil {
+			return err
+		}
+		report.Decode = results
+	}
+
+	var cfg ttscrape_go.Config
+	if len(modeNames) > 0 {
+		var err error
+		if _, cfg, err = cf.configuredAPI(); err != nil {
+			return err
+		}
+		report.Target = cfg.BaseURL
+	}
+
+	// Run the workload once per mode, each with fresh sessions
+	var errs []error
+	for _, name := range modeNames {
+		result, err := benchMode(ctx, cfg, name, workload)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		report.Modes = append(report.Modes, result)
+	}
+
+	if *out != "" {
+		if err := writeReport(*out, report); err != nil {
+			return err
+		}
+	}
+	if err := cf.output(stdout).write(report); err != nil {
+		return err
+	}
+
+	// The report records modes that could not start; fail only if none ran
+	if len(modeNames) > 0 && len(errs) == len(modeNames) {
+		return &sessionError{err: errors.Join(errs...)}
+	}
+	return nil
+}
+
+// benchMode creates sessions in the named mode and runs the workload on them
+func benchMode(ctx context.Context, cfg ttscrape_go.Config, name string, workload bench.Workload) (bench.Result, error) {
+	benchModes[name](&cfg)
+
+	api, err := cfg.NewAPI()
+	if err != nil {
+		return bench.Result{Mode: name, Error: err.Error()}, err
+	}
+	defer api.Close()
+
+	start := time.Now()
+	if err := cfg.StartSessions(ctx, api); err != nil {
+		return bench.Result{Mode: name, Error: err.Error()}, err
+	}
+	setup := time.Since(start)
+
+	result := bench.Run(ctx, api, workload)
+	result.Mode = name
+	result.SetupMS = float64(setup) / float64(time.Millisecond)
+	return result, nil
+}
+
+// startStandin runs "ttscrape standin" in a child process, so its allocations
+// are not counted in the report, and returns its URL and a function that
+// stops it
+func startStandin(ids []string, videos int, delay time.Duration) (string, func(), error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", nil, fmt.Errorf("start stand-in: %w", err)
+	}
+
+	cmd := exec.Command(exe, "standin",
+		"-sounds", strings.Join(ids, ","),
+		"-videos", strconv.Itoa(videos),
+		"-delay", delay.String(),
+	)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", nil, fmt.Errorf("start stand-in: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return "", nil, fmt.Errorf("start stand-in: %w", err)
+	}
+	stop := func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}
+
+	// The first line of output is the server URL
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		stop()
+		return "", nil, fmt.Errorf("start stand-in: %w", err)
+	}
+	return strings.TrimSpace(line), stop, nil
+}
+
+// readIDFile reads the IDs in path. See bulk.ReadIDs for the accepted format.
+func readIDFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open ID file: %w", err)
+	}
+	defer file.Close()
+
+	var ids []string
+	err = bulk.ReadIDs(file, func(id string) bool {
+		ids = append(ids, id)
+		return true
+	})
+	return ids, err
+}
+
+// writeReport writes report to path as indented JSON
+func writeReport(path string, report bench.Report) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := newOutput(file, "json", nil).write(report); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runStandin implements "standin"
+func runStandin(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("standin", flag.ContinueOnError)
+	sounds := fs.String("sounds", "", "Comma-separated sound IDs to serve generated fixtures for")
+	videos := fs.Int("videos", 300, "Videos generated per sound")
+	fixtures := fs.String("fixtures", "", "Also serve the sounds in this results.json file from the concurrent example")
+	pageSize := fs.Int("page-size", ttscrapetest.DefaultPageSize, "Maximum videos returned per item_list page")
+	delay := fs.Duration("delay", 0, "Delay added to every response")
+
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	server := ttscrapetest.NewServer()
+	defer server.Close()
+
+	for _, id := range splitList(*sounds) {
+		server.AddSound(id, ttscrapetest.SyntheticFixture(id, *videos))
+	}
+	if *fixtures != "" {
+		if _, err := server.LoadResultsFile(*fixtures); err != nil {
+			return err
+		}
+	}
+	server.SetPageSize(*pageSize)
+	if *delay > 0 {
+		server.SetFault("*", ttscrapetest.Fault{Delay: *delay})
+	}
+
+	// Print the URL for -base-url and serve until interrupted
+	fmt.Fprintln(stdout, server.URL)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/fortindustries/ttscrape-go/bulk"
+	"github.com/fortindustries/ttscrape-go/metrics"
+	"github.com/fortindustries/ttscrape-go/store"
+)
+
+// runBulkSounds implements "bulk sounds"
+func runBulkSounds(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("bulk sounds", flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	out := fs.String("o", "results.jsonl", "JSON Lines file results are appended to")
+	checkpoint := fs.String("checkpoint", "", "File completed IDs are recorded in (default <o>.checkpoint)")
+	workers := fs.Int("workers", 4, "Number of sounds fetched concurrently")
+	videos := fs.Int("videos", 0, "Number of videos to fetch per sound")
+	dbPath := fs.String("db", "", "Also save every sound and its videos into this SQLite database")
+	metricsAddr := fs.String("metrics-addr", "", "Serve Prometheus metrics on this address at /metrics while running")
+	skipFailed := fs.Bool("skip-failed", false, "Do not retry failed IDs when the run is resumed")
+
+	// Bulk runs are long, so they have no overall timeout unless one is given
+	cf.timeout = 0
+	fs.Lookup("timeout").DefValue = "0s"
+
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *workers < 1 {
+		return &usageError{msg: "-workers must be at least 1"}
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+
+	// Stop feeding new IDs on Ctrl-C; the checkpoint lets the run resume later
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	// Export metrics for the length of the run
+	if *metricsAddr != "" {
+		cf.metrics = metrics.NewPrometheus()
+		stopMetrics, err := serveMetrics(*metricsAddr, cf.metrics)
+		if err != nil {
+			return err
+		}
+		defer stopMetrics()
+	}
+
+	api, err := cf.newAPI(ctx)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	opts := bulk.Options{
+		Workers:    *workers,
+		Output:     *out,
+		Checkpoint: *checkpoint,
+		SkipFailed: *skipFailed,
+	}
+
+	// Save into the database as results are written. The results file stays the
+	// source of truth, so the first failed save is reported but the run goes on.
+	var saveErr error
+	if *dbPath != "" {
+		db, err := store.Open(ctx, *dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		opts.OnResult = func(result bulk.Result) {
+			sound, ok := result.Data.(bulk.SoundResult)
+			if !ok || saveErr != nil {
+				return
+			}
+			if err := saveSoundResult(ctx, db, result.ID, sound); err != nil {
+				saveErr = fmt.Errorf("save sound %s: %w", result.ID, err)
+			}
+		}
+	}
+
+	stats, err := bulk.RunFile(ctx, positional[0], bulk.SoundJob(api, *videos), opts)
+	if err == nil {
+		err = saveErr
+	}
+	if writeErr := cf.output(stdout).write(map[string]interface{}{
+		"total":      stats.Total,
+		"skipped":    stats.Skipped,
+		"succeeded":  stats.Succeeded,
+		"failed":     stats.Failed,
+		"elapsed_ms": stats.Elapsed.Milliseconds(),
+		"output":     *out,
+	}); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// saveSoundResult saves a sound and its videos as produced by bulk.SoundJob
+func saveSoundResult(ctx context.Context, db *store.Store, id string, result bulk.SoundResult) error {
+	if err := db.SaveSound(ctx, result.Info); err != nil {
+		return err
+	}
+	if len(result.Videos) == 0 {
+		return nil
+	}
+	return db.SaveVideos(ctx, id, result.Videos)
+}
+
+// serveMetrics serves m on addr at /metrics until the returned function is called
+func serveMetrics(addr string, m *metrics.Prometheus) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(listener)
+
+	return func() { srv.Close() }, nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/export"
+	"github.com/fortindustries/ttscrape-go/metrics"
+)
+
+// clientFlags are the flags shared by every command that talks to TikTok
+type clientFlags struct {
+	fs *flag.FlagSet
+
+	configPath   string
+	headless     bool
+	browserFree  bool
+	sessions     int
+	msTokens     string
+	msTokenFile  string
+	proxies      string
+	sleepAfter   int
+	baseURL      string
+	pageFallback bool
+	timeout      time.Duration
+	format       string
+	columns      string
+	retries      int
+	rateLimit    float64
+	logLevel     string
+
+	metrics *metrics.Prometheus // Set by commands that export metrics
+}
+
+// addClientFlags registers the shared flags on fs
+func addClientFlags(fs *flag.FlagSet) *clientFlags {
+	f := &clientFlags{fs: fs}
+	fs.StringVar(&f.configPath, "config", "", "YAML or JSON config file, overridden by $TTSCRAPE_* variables and then by flags")
+	fs.BoolVar(&f.headless, "headless", true, "Run the browser in headless mode")
+	fs.BoolVar(&f.browserFree, "browser-free", true, "Operate without a browser after initial setup")
+	fs.IntVar(&f.sessions, "sessions", 1, "Number of sessions to create, 0 for one per msToken")
+	fs.StringVar(&f.msTokens, "ms-token", "", "Comma-separated msTokens (default $TTSCRAPE_MS_TOKENS or $ms_token)")
+	fs.StringVar(&f.msTokenFile, "ms-token-file", "", "File with one msToken per line")
+	fs.StringVar(&f.proxies, "proxies", "", "Comma-separated proxy URLs assigned to sessions round-robin")
+	fs.IntVar(&f.sleepAfter, "sleep-after", 3, "Seconds to wait after opening each browser session")
+	fs.StringVar(&f.baseURL, "base-url", "", "Base URL for every endpoint, e.g. a mirror")
+	fs.BoolVar(&f.pageFallback, "page-fallback", false, "Fall back to server-rendered pages when an endpoint fails")
+	fs.IntVar(&f.retries, "retries", 2, "Times a request is retried after a network error or a 429/5xx response")
+	fs.Float64Var(&f.rateLimit, "rate-limit", 0, "Maximum API requests per second per session, 0 for no limit")
+	fs.StringVar(&f.logLevel, "log-level", "warn", "Log to stderr at this level and above: debug, info, warn or error")
+	fs.DurationVar(&f.timeout, "timeout", 2*time.Minute, "Overall timeout for the command, 0 for no limit")
+	fs.StringVar(&f.format, "format", "json", "Output format: json, jsonl or csv")
+	fs.StringVar(&f.columns, "columns", "", "Comma-separated CSV columns as dotted paths or name=path, e.g. id,author.uniqueId,plays=stats.playCount")
+	return f
+}
+
+// configFlags maps flags to the config settings they control
+var configFlags = map[string]func(f *clientFlags, cfg *ttscrape_go.Config){
+	"headless":      func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.Headless = f.headless },
+	"browser-free":  func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.BrowserFree = f.browserFree },
+	"sessions":      func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.Sessions = f.sessions },
+	"ms-token":      func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.MsTokens = splitList(f.msTokens) },
+	"ms-token-file": func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.MsTokenFile = f.msTokenFile },
+	"proxies":       func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.Proxies = splitList(f.proxies) },
+	"sleep-after":   func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.SleepAfter = f.sleepAfter },
+	"base-url":      func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.BaseURL = f.baseURL },
+	"page-fallback": func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.PageFallback = f.pageFallback },
+	"retries":       func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.MaxRetries = f.retries },
+	"rate-limit":    func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.RateLimit = f.rateLimit },
+	"log-level":     func(f *clientFlags, cfg *ttscrape_go.Config) { cfg.LogLevel = f.logLevel },
+}
+
+// config resolves the client settings. Flag defaults come first, then the
+// -config file, then $TTSCRAPE_* variables and finally the flags that were set.
+func (f *clientFlags) config() (ttscrape_go.Config, error) {
+	set := map[string]bool{}
+	f.fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+
+	cfg := ttscrape_go.DefaultConfig()
+	for name, apply := range configFlags {
+		if !set[name] {
+			apply(f, &cfg)
+		}
+	}
+	if f.configPath != "" {
+		if err := cfg.LoadFile(f.configPath); err != nil {
+			return cfg, err
+		}
+	}
+	if err := cfg.LoadEnv(); err != nil {
+		return cfg, err
+	}
+	for name, apply := range configFlags {
+		if set[name] {
+			apply(f, &cfg)
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// newAPI creates a TikTokAPI with sessions ready for requests.
+// The caller must call Close on the returned API.
+func (f *clientFlags) newAPI(ctx context.Context) (*ttscrape_go.TikTokAPI, error) {
+	api, cfg, err := f.configuredAPI()
+	if err != nil {
+		return nil, err
+	}
+	if err := f.createSessions(ctx, api, cfg); err != nil {
+		return nil, err
+	}
+	return api, nil
+}
+
+// configuredAPI validates the flags and returns a TikTokAPI configured from
+// them, without sessions, along with the config to create sessions with
+func (f *clientFlags) configuredAPI() (*ttscrape_go.TikTokAPI, ttscrape_go.Config, error) {
+	if f.format != "json" && f.format != "jsonl" && f.format != "csv" {
+		return nil, ttscrape_go.Config{}, &usageError{msg: fmt.Sprintf("unknown -format %q", f.format)}
+	}
+
+	cfg, err := f.config()
+	if err != nil {
+		return nil, cfg, err
+	}
+
+	tokens, err := cfg.Tokens()
+	if err != nil {
+		return nil, cfg, err
+	}
+	if len(tokens) == 0 {
+		return nil, cfg, &usageError{msg: "no msToken: set -ms-token, -ms-token-file, $TTSCRAPE_MS_TOKENS or $ms_token"}
+	}
+
+	api, err := cfg.NewAPI()
+	if err != nil {
+		return nil, cfg, err
+	}
+	if f.metrics != nil {
+		api.SetMetrics(f.metrics)
+	}
+	return api, cfg, nil
+}
+
+// createSessions creates the sessions of api, closing it on failure
+func (f *clientFlags) createSessions(ctx context.Context, api *ttscrape_go.TikTokAPI, cfg ttscrape_go.Config) error {
+	if err := cfg.StartSessions(ctx, api); err != nil {
+		api.Close()
+		return &sessionError{err: err}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping blank entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// context returns the command context honouring -timeout, where 0 means no limit
+func (f *clientFlags) context() (context.Context, context.CancelFunc) {
+	if f.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), f.timeout)
+}
+
+// output returns the writer for results in the selected format
+func (f *clientFlags) output(w io.Writer) *output {
+	return newOutput(w, f.format, export.ParseColumns(f.columns))
+}
+
+// parseArgs parses flags that may appear before, between or after positional
+// arguments and checks the number of positional arguments
+func parseArgs(fs *flag.FlagSet, args []string, wantArgs int) ([]string, error) {
+	fs.SetOutput(io.Discard)
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintf(os.Stdout, "Usage of %s:\n", fs.Name())
+				fs.SetOutput(os.Stdout)
+				fs.PrintDefaults()
+				return nil, err
+			}
+			return nil, &usageError{msg: err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) != wantArgs {
+		return nil, &usageError{msg: fmt.Sprintf("%s expects %d argument(s), got %d", fs.Name(), wantArgs, len(positional))}
+	}
+	return positional, nil
+}
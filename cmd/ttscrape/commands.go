@@ -0,0 +1,450 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/export"
+	"github.com/fortindustries/ttscrape-go/store"
+)
+
+// runInfo is shared by the "<entity> info" commands
+func runInfo(name string, args []string, stdout io.Writer, info func(ctx context.Context, api *ttscrape_go.TikTokAPI, arg string) (map[string]interface{}, error)) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+
+	api, err := cf.newAPI(ctx)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	resp, err := info(ctx, api, positional[0])
+	if err != nil {
+		return err
+	}
+	if err := ttscrape_go.CheckStatus(resp); err != nil {
+		return err
+	}
+
+	return cf.output(stdout).write(resp)
+}
+
+// listFlags are the flags shared by the listing commands
+type listFlags struct {
+	count  int
+	cursor int
+}
+
+// addListFlags registers the listing flags on fs
+func addListFlags(fs *flag.FlagSet, defaultCount int) *listFlags {
+	f := &listFlags{}
+	fs.IntVar(&f.count, "count", defaultCount, "Maximum number of items to list")
+	fs.IntVar(&f.cursor, "cursor", 0, "Cursor to start paging from")
+	return f
+}
+
+// pagingError returns an option storing the error that ends paging early in err
+func pagingError(err *error) ttscrape_go.RequestOption {
+	return ttscrape_go.WithPagingError(func(pagingErr error) { *err = pagingErr })
+}
+
+// runSoundInfo implements "sound info"
+func runSoundInfo(args []string, stdout io.Writer) error {
+	return runInfo("sound info", args, stdout, func(ctx context.Context, api *ttscrape_go.TikTokAPI, id string) (map[string]interface{}, error) {
+		return api.Sound(id).Info(ctx)
+	})
+}
+
+// runSoundVideos implements "sound videos"
+func runSoundVideos(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("sound videos", flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	lf := addListFlags(fs, 30)
+	statePath := fs.String("state", "", "Only list videos newer than the last run, remembered in this JSON file")
+	stateDB := fs.String("state-db", "", "Like -state, but remembered in this SQLite database")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *statePath != "" && *stateDB != "" {
+		return &usageError{msg: "-state and -state-db cannot be combined"}
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+
+	// Open the incremental crawl state, if any
+	var state ttscrape_go.CrawlStateStore
+	switch {
+	case *statePath != "":
+		if state, err = ttscrape_go.NewFileCrawlState(*statePath); err != nil {
+			return err
+		}
+	case *stateDB != "":
+		db, err := store.Open(ctx, *stateDB)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		state = db
+	}
+
+	api, err := cf.newAPI(ctx)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	sound := api.Sound(positional[0])
+	var pagingErr error
+	var videos chan map[string]interface{}
+	if state != nil {
+		videos, err = sound.NewVideos(ctx, state, lf.count, pagingError(&pagingErr))
+	} else {
+		videos, err = sound.Videos(ctx, lf.count, lf.cursor, pagingError(&pagingErr))
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := writeStream(cf.output(stdout).defaultColumns(export.DefaultVideoColumns), videos, func(video map[string]interface{}) interface{} {
+		return video
+	}); err != nil {
+		return err
+	}
+	return pagingErr
+}
+
+// runSoundDownload implements "sound download"
+func runSoundDownload(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("sound download", flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	out := fs.String("o", "", "Output file (default <id>.mp3)")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	id := positional[0]
+	if *out == "" {
+		*out = id + ".mp3"
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+
+	api, err := cf.newAPI(ctx)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	n, err := api.Sound(id).DownloadAudio(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	return cf.output(stdout).write(map[string]interface{}{
+		"id":    id,
+		"file":  *out,
+		"bytes": n,
+	})
+}
+
+// runVideoInfo implements "video info"
+func runVideoInfo(args []string, stdout io.Writer) error {
+	return runInfo("video info", args, stdout, func(ctx context.Context, api *ttscrape_go.TikTokAPI, id string) (map[string]interface{}, error) {
+		return api.Video(id).Info(ctx)
+	})
+}
+
+// runVideoDownload implements "video download"
+func runVideoDownload(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("video download", flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	out := fs.String("o", "", "Output file (default <id>.mp4)")
+	codec := fs.String("codec", "", "Only consider renditions with this codec, e.g. h264")
+	maxSize := fs.Int64("max-size", 0, "Only consider renditions up to this many bytes")
+	lowest := fs.Bool("lowest", false, "Pick the lowest bitrate instead of the highest")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	id := positional[0]
+	if *out == "" {
+		*out = id + ".mp4"
+	}
+
+	// Build the rendition selector
+	selector := ttscrape_go.RenditionSelector(ttscrape_go.HighestBitrate)
+	if *lowest {
+		selector = ttscrape_go.LowestBitrate
+	}
+	if *maxSize > 0 {
+		selector = ttscrape_go.WithMaxSize(*maxSize, selector)
+	}
+	if *codec != "" {
+		selector = ttscrape_go.WithCodec(*codec, selector)
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+
+	api, err := cf.newAPI(ctx)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	n, err := api.Video(id).Download(ctx, file, selector)
+	if err != nil {
+		return err
+	}
+
+	return cf.output(stdout).write(map[string]interface{}{
+		"id":    id,
+		"file":  *out,
+		"bytes": n,
+	})
+}
+
+// runUserInfo implements "user info"
+func runUserInfo(args []string, stdout io.Writer) error {
+	return runInfo("user info", args, stdout, func(ctx context.Context, api *ttscrape_go.TikTokAPI, username string) (map[string]interface{}, error) {
+		return api.User(username).Info(ctx)
+	})
+}
+
+// runUserPlaylists implements "user playlists"
+func runUserPlaylists(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("user playlists", flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	lf := addListFlags(fs, 20)
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+
+	api, err := cf.newAPI(ctx)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	var pagingErr error
+	playlists, err := api.User(positional[0]).Playlists(ctx, lf.count, lf.cursor, pagingError(&pagingErr))
+	if err != nil {
+		return err
+	}
+
+	if _, err := writeStream(cf.output(stdout), playlists, func(playlist *ttscrape_go.Playlist) interface{} {
+		return playlist.AsDict
+	}); err != nil {
+		return err
+	}
+	return pagingErr
+}
+
+// runHashtagInfo implements "hashtag info"
+func runHashtagInfo(args []string, stdout io.Writer) error {
+	return runInfo("hashtag info", args, stdout, func(ctx context.Context, api *ttscrape_go.TikTokAPI, name string) (map[string]interface{}, error) {
+		return api.Hashtag(name).Info(ctx)
+	})
+}
+
+// runHashtagVideos implements "hashtag videos"
+func runHashtagVideos(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("hashtag videos", flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	lf := addListFlags(fs, 30)
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+
+	api, err := cf.newAPI(ctx)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	var pagingErr error
+	videos, err := api.Hashtag(positional[0]).Videos(ctx, lf.count, lf.cursor, pagingError(&pagingErr))
+	if err != nil {
+		return err
+	}
+
+	if _, err := writeStream(cf.output(stdout).defaultColumns(export.DefaultVideoColumns), videos, videoJSON); err != nil {
+		return err
+	}
+	return pagingErr
+}
+
+// runPlaylistInfo implements "playlist info"
+func runPlaylistInfo(args []string, stdout io.Writer) error {
+	return runInfo("playlist info", args, stdout, func(ctx context.Context, api *ttscrape_go.TikTokAPI, id string) (map[string]interface{}, error) {
+		return api.Playlist(id).Info(ctx)
+	})
+}
+
+// runPlaylistVideos implements "playlist videos"
+func runPlaylistVideos(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("playlist videos", flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	lf := addListFlags(fs, 30)
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+
+	api, err := cf.newAPI(ctx)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	var pagingErr error
+	videos, err := api.Playlist(positional[0]).Videos(ctx, lf.count, lf.cursor, pagingError(&pagingErr))
+	if err != nil {
+		return err
+	}
+
+	if _, err := writeStream(cf.output(stdout).defaultColumns(export.DefaultVideoColumns), videos, videoJSON); err != nil {
+		return err
+	}
+	return pagingErr
+}
+
+// runTrending implements "trending"
+func runTrending(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("trending", flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	count := fs.Int("count", 30, "Number of videos to list")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+
+	api, err := cf.newAPI(ctx)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	var pagingErr error
+	videos, err := api.Trending(ctx, *count, pagingError(&pagingErr))
+	if err != nil {
+		return err
+	}
+
+	if _, err := writeStream(cf.output(stdout).defaultColumns(export.DefaultVideoColumns), videos, videoJSON); err != nil {
+		return err
+	}
+	return pagingErr
+}
+
+// runResolve implements "resolve". Canonical URLs are parsed offline; only
+// short links need a session.
+func runResolve(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ttscrape_go.ParseURL(positional[0])
+	if err == nil {
+		return cf.output(stdout).write(parsedJSON(parsed))
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+
+	api, err := cf.newAPI(ctx)
+	if err != nil {
+		return err
+	}
+	defer api.Close()
+
+	entity, err := api.Resolve(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	return cf.output(stdout).write(entityJSON(entity))
+}
+
+// videoJSON returns the item struct of a video
+func videoJSON(video *ttscrape_go.Video) interface{} {
+	return video.AsDict
+}
+
+// parsedJSON describes a parsed URL
+func parsedJSON(parsed ttscrape_go.ParsedURL) map[string]interface{} {
+	result := map[string]interface{}{
+		"kind": parsed.Kind,
+	}
+	if parsed.ID != "" {
+		result["id"] = parsed.ID
+	}
+	if parsed.Username != "" {
+		result["username"] = parsed.Username
+	}
+	if parsed.Name != "" {
+		result["name"] = parsed.Name
+	}
+	return result
+}
+
+// entityJSON describes a resolved entity
+func entityJSON(entity interface{}) map[string]interface{} {
+	switch e := entity.(type) {
+	case *ttscrape_go.Sound:
+		return parsedJSON(ttscrape_go.ParsedURL{Kind: ttscrape_go.KindSound, ID: e.ID})
+	case *ttscrape_go.Video:
+		return parsedJSON(ttscrape_go.ParsedURL{Kind: ttscrape_go.KindVideo, ID: e.ID, Username: e.AuthorUsername})
+	case *ttscrape_go.User:
+		return parsedJSON(ttscrape_go.ParsedURL{Kind: ttscrape_go.KindUser, Username: e.Username})
+	case *ttscrape_go.Hashtag:
+		return parsedJSON(ttscrape_go.ParsedURL{Kind: ttscrape_go.KindHashtag, Name: e.Name})
+	case *ttscrape_go.Playlist:
+		return parsedJSON(ttscrape_go.ParsedURL{Kind: ttscrape_go.KindPlaylist, ID: e.ID})
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fortindustries/ttscrape-go/bulk"
+	"github.com/fortindustries/ttscrape-go/export"
+)
+
+// bulkLine is a line of a "bulk sounds" results file
+type bulkLine struct {
+	ID    string            `json:"id"`
+	Data  *bulk.SoundResult `json:"data"`
+	Error string            `json:"error"`
+}
+
+// runExport implements "export"
+func runExport(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	dir := fs.String("dir", "export", "Directory the sounds, videos and authors tables are written to")
+	format := fs.String("format", "csv", "Table format: csv or jsonl")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *format != string(export.FormatCSV) && *format != string(export.FormatJSONL) {
+		return &usageError{msg: fmt.Sprintf("unknown -format %q", *format)}
+	}
+
+	file, err := os.Open(positional[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tables, err := export.NewTables(*dir, export.Format(*format))
+	if err != nil {
+		return err
+	}
+
+	sounds, videos, err := exportBulkResults(file, tables)
+	if closeErr := tables.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return newOutput(stdout, "json", nil).write(map[string]interface{}{
+		"dir":    *dir,
+		"sounds": sounds,
+		"videos": videos,
+	})
+}
+
+// exportBulkResults writes every successful sound in a bulk results file to tables.
+// Results for the same sound from resumed runs are written once, and partial
+// lines left by a crash are skipped.
+func exportBulkResults(r io.Reader, tables *export.Tables) (int, int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+
+	seen := make(map[string]bool)
+	sounds, videos := 0, 0
+	for scanner.Scan() {
+		var line bulkLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		if line.Error != "" || line.Data == nil || seen[line.ID] {
+			continue
+		}
+		seen[line.ID] = true
+
+		if err := tables.WriteSound(line.Data.Info); err != nil {
+			return sounds, videos, err
+		}
+		sounds++
+		for _, video := range line.Data.Videos {
+			if err := tables.WriteVideo(line.ID, video); err != nil {
+				return sounds, videos, err
+			}
+			videos++
+		}
+	}
+	return sounds, videos, scanner.Err()
+}
@@ -0,0 +1,155 @@
+// Command ttscrape fetches TikTok sounds, videos, users, hashtags and
+// playlists from the command line.
+//
+// Usage:
+//
+//	ttscrape <entity> <action> [arguments] [flags]
+//
+// Run "ttscrape help" for the list of commands.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes
+const (
+	exitOK      = 0 // Success
+	exitError   = 1 // A request failed or TikTok returned an error
+	exitUsage   = 2 // Bad command line
+	exitSession = 3 // Sessions could not be created
+)
+
+// usageError is returned for bad command lines
+type usageError struct {
+	msg string
+}
+
+// Error returns the usage message
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// sessionError is returned when sessions cannot be created
+type sessionError struct {
+	err error
+}
+
+// Error returns the underlying error message
+func (e *sessionError) Error() string {
+	return fmt.Sprintf("create sessions: %v", e.err)
+}
+
+// Unwrap returns the underlying error
+func (e *sessionError) Unwrap() error {
+	return e.err
+}
+
+// command is a single "<entity> <action>" handler
+type command struct {
+	Entity  string
+	Action  string
+	Args    string // Positional argument names for usage output
+	Summary string
+	Run     func(args []string, stdout io.Writer) error
+}
+
+// commands lists every subcommand in usage order
+var commands = []command{
+	{"sound", "info", "<id>", "Show sound information", runSoundInfo},
+	{"sound", "videos", "<id>", "List videos that use a sound", runSoundVideos},
+	{"sound", "download", "<id>", "Download a sound's audio", runSoundDownload},
+	{"video", "info", "<id>", "Show video information", runVideoInfo},
+	{"video", "download", "<id>", "Download a video file", runVideoDownload},
+	{"user", "info", "<username>", "Show user information", runUserInfo},
+	{"user", "playlists", "<username>", "List a user's playlists", runUserPlaylists},
+	{"hashtag", "info", "<name>", "Show hashtag information", runHashtagInfo},
+	{"hashtag", "videos", "<name>", "List videos tagged with a hashtag", runHashtagVideos},
+	{"playlist", "info", "<id>", "Show playlist information", runPlaylistInfo},
+	{"playlist", "videos", "<id>", "List videos in a playlist", runPlaylistVideos},
+	{"trending", "", "", "List videos from the For You feed", runTrending},
+	{"bulk", "sounds", "<id-file>", "Fetch every sound in an ID file, resuming from a checkpoint", runBulkSounds},
+	{"export", "", "<results.jsonl>", "Write bulk results as sounds, videos and authors tables", runExport},
+	{"serve", "", "", "Serve TikTok data over a JSON REST API", runServe},
+	{"resolve", "", "<url>", "Resolve a TikTok link to the entity it points at", runResolve},
+	{"bench", "", "", "Benchmark a sound workload and report throughput, latency and allocations", runBench},
+	{"standin", "", "", "Serve a local TikTok stand-in with generated sounds", runStandin},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run dispatches to a subcommand and returns the process exit code
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdout)
+		return exitOK
+	}
+
+	cmd, rest, ok := findCommand(args)
+	if !ok {
+		fmt.Fprintf(stderr, "ttscrape: unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	err := cmd.Run(rest, stdout)
+
+	var usageErr *usageError
+	var sessionErr *sessionError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "ttscrape: %v\n", err)
+		return exitUsage
+	case errors.As(err, &sessionErr):
+		fmt.Fprintf(stderr, "ttscrape: %v\n", err)
+		return exitSession
+	default:
+		fmt.Fprintf(stderr, "ttscrape: %v\n", err)
+		return exitError
+	}
+}
+
+// findCommand finds the command named by the leading arguments
+func findCommand(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		if args[0] != cmd.Entity {
+			continue
+		}
+		if cmd.Action == "" {
+			return cmd, args[1:], true
+		}
+		if len(args) > 1 && args[1] == cmd.Action {
+			return cmd, args[2:], true
+		}
+	}
+	return command{}, nil, false
+}
+
+// printUsage prints the list of commands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: ttscrape <entity> <action> [arguments] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		name := cmd.Entity
+		if cmd.Action != "" {
+			name += " " + cmd.Action
+		}
+		if cmd.Args != "" {
+			name += " " + cmd.Args
+		}
+		fmt.Fprintf(w, "  %-32s %s\n", name, cmd.Summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run \"ttscrape <entity> <action> -h\" for the flags of a command.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Exit codes: 0 success, 1 request failed, 2 usage error, 3 session creation failed")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// runAgainst runs the CLI with args against the stand-in srv
+func runAgainst(t *testing.T, srv *ttscrapetest.Server, args ...string) (int, string, string) {
+	t.Helper()
+	args = append(args, "-base-url", srv.URL, "-ms-token", "test-token", "-retries", "0")
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSoundVideos(t *testing.T) {
+	srv := ttscrapetest.NewServer()
+	defer srv.Close()
+	srv.AddSound("1", ttscrapetest.SyntheticFixture("1", 45))
+	srv.SetPageSize(10)
+
+	code, stdout, stderr := runAgainst(t, srv, "sound", "videos", "-count", "25", "-format", "jsonl", "1")
+	if code != exitOK {
+		t.Fatalf("exit code %d, stderr %q", code, stderr)
+	}
+	if lines := strings.Count(stdout, "\n"); lines != 25 {
+		t.Errorf("got %d videos, want 25", lines)
+	}
+}
+
+func TestSoundVideosPagingError(t *testing.T) {
+	srv := ttscrapetest.NewServer()
+	defer srv.Close()
+	srv.AddSound("1", ttscrapetest.SyntheticFixture("1", 45))
+	srv.SetFault(ttscrapetest.PathMusicItemList, ttscrapetest.Fault{Captcha: true})
+
+	code, _, stderr := runAgainst(t, srv, "sound", "videos", "-format", "jsonl", "1")
+	if code != exitError {
+		t.Fatalf("exit code %d, want %d", code, exitError)
+	}
+	if stderr == "" {
+		t.Error("no error reported on stderr")
+	}
+}
+
+func TestSoundInfoNotFound(t *testing.T) {
+	srv := ttscrapetest.NewServer()
+	defer srv.Close()
+
+	code, _, stderr := runAgainst(t, srv, "sound", "info", "2")
+	if code != exitError {
+		t.Fatalf("exit code %d, want %d", code, exitError)
+	}
+	if !strings.Contains(stderr, "10218") {
+		t.Errorf("stderr %q does not report the TikTok status", stderr)
+	}
+}
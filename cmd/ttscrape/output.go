@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/fortindustries/ttscrape-go/export"
+)
+
+// output writes results as pretty JSON documents, JSON Lines or CSV
+type output struct {
+	w       io.Writer
+	format  string
+	columns []export.Column
+}
+
+// newOutput returns an output for the given format. columns selects the CSV
+// columns and may be empty.
+func newOutput(w io.Writer, format string, columns []export.Column) *output {
+	return &output{w: w, format: format, columns: columns}
+}
+
+// defaultColumns sets the CSV columns used when none were given on the command line
+func (o *output) defaultColumns(columns []export.Column) *output {
+	if len(o.columns) == 0 {
+		o.columns = columns
+	}
+	return o
+}
+
+// write writes a single result
+func (o *output) write(v interface{}) error {
+	if o.format == "csv" {
+		if record, ok := v.(map[string]interface{}); ok {
+			csvw := export.NewCSVWriter(o.w, o.columns)
+			if err := csvw.Write(record); err != nil {
+				return err
+			}
+			return csvw.Flush()
+		}
+	}
+
+	enc := json.NewEncoder(o.w)
+	if o.format == "json" {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// writeStream writes every item received from items. In json format the items
+// are collected into a single array; in jsonl and csv format each is written as it arrives.
+func writeStream[T any](o *output, items <-chan T, toJSON func(T) interface{}) (int, error) {
+	count := 0
+	switch o.format {
+	case "jsonl":
+		jsonl := export.NewJSONLWriter(o.w)
+		for item := range items {
+			if err := jsonl.Write(toJSON(item)); err != nil {
+				return count, err
+			}
+			count++
+		}
+		return count, jsonl.Flush()
+	case "csv":
+		csvw := export.NewCSVWriter(o.w, o.columns)
+		for item := range items {
+			record, _ := toJSON(item).(map[string]interface{})
+			if err := csvw.Write(record); err != nil {
+				return count, err
+			}
+			count++
+		}
+		return count, csvw.Flush()
+	}
+
+	all := make([]interface{}, 0)
+	for item := range items {
+		all = append(all, toJSON(item))
+		count++
+	}
+	return count, o.write(all)
+}
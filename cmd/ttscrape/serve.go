@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fortindustries/ttscrape-go/metrics"
+	"github.com/fortindustries/ttscrape-go/server"
+)
+
+// shutdownGrace is how long in-flight requests get to finish on shutdown
+const shutdownGrace = 15 * time.Second
+
+// runServe implements "serve"
+func runServe(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	cf := addClientFlags(fs)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	requestTimeout := fs.Duration("request-timeout", 60*time.Second, "Time limit per request")
+	maxCount := fs.Int("max-count", 500, "Largest count a listing request may ask for")
+
+	// The server runs until it is stopped, so it has no overall timeout unless one is given
+	cf.timeout = 0
+	fs.Lookup("timeout").DefValue = "0s"
+
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	cf.metrics = metrics.NewPrometheus()
+	api, cfg, err := cf.configuredAPI()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cf.context()
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+
+	srv := server.New(api, server.Options{MaxCount: *maxCount, Timeout: *requestTimeout})
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", cf.metrics)
+	mux.Handle("/", srv.Handler())
+	httpServer := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Serve straight away so health checks pass; /readyz reports 503 until the sessions exist
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+	fmt.Fprintf(stdout, "ttscrape: listening on %s\n", listener.Addr())
+
+	sessionErr := make(chan error, 1)
+	go func() {
+		if err := cf.createSessions(ctx, api, cfg); err != nil {
+			sessionErr <- err
+			return
+		}
+		srv.SetReady(true)
+		fmt.Fprintf(stdout, "ttscrape: %d session(s) ready\n", api.SessionCount())
+	}()
+
+	select {
+	case err = <-serveErr:
+	case err = <-sessionErr:
+	case <-ctx.Done():
+	}
+
+	// Stop accepting requests and let in-flight ones finish
+	srv.SetReady(false)
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownGrace)
+	defer cancelShutdown()
+	if shutdownErr := httpServer.Shutdown(shutdownCtx); err == nil {
+		err = shutdownErr
+	}
+
+	// Then drain TikTok requests and paging left behind by cancelled handlers
+	if shutdownErr := api.Shutdown(shutdownCtx); err == nil {
+		err = shutdownErr
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,334 @@
+package ttscrape_go
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds everything needed to build a ready TikTokAPI. Load it with
+// LoadConfig, or fill it in and pass it to NewTikTokAPIFromConfig.
+type Config struct {
+	MsTokens    []string `yaml:"ms_tokens" json:"ms_tokens"`         // msTokens, one per session
+	MsTokenFile string   `yaml:"ms_token_file" json:"ms_token_file"` // File with more msTokens, one per line
+	Proxies     []string `yaml:"proxies" json:"proxies"`             // Proxy URLs assigned to sessions round-robin
+	Sessions    int      `yaml:"sessions" json:"sessions"`           // Number of sessions, 0 for one per msToken
+
+	Headless    bool   `yaml:"headless" json:"headless"`         // Run the browser without a window
+	BrowserFree bool   `yaml:"browser_free" json:"browser_free"` // Operate without a browser after initial setup
+	BrowserPath string `yaml:"browser_path" json:"browser_path"` // Chrome or Chromium executable, found automatically if empty
+	SleepAfter  int    `yaml:"sleep_after" json:"sleep_after"`   // Seconds to wait after opening each browser session
+
+	BaseURL      string `yaml:"base_url" json:"base_url"`           // Base URL for every endpoint, e.g. a mirror
+	PageFallback bool   `yaml:"page_fallback" json:"page_fallback"` // Fall back to server-rendered pages when an endpoint fails
+	LogLevel     string `yaml:"log_level" json:"log_level"`         // debug, info, warn, error or a Python-style number
+
+	MaxRetries     int      `yaml:"max_retries" json:"max_retries"`         // Retries after a network error or a 429/5xx response
+	RateLimit      float64  `yaml:"rate_limit" json:"rate_limit"`           // API requests per second per session, 0 for no limit
+	RateBurst      int      `yaml:"rate_burst" json:"rate_burst"`           // Requests a session may make at once
+	RequestTimeout Duration `yaml:"request_timeout" json:"request_timeout"` // Timeout of each API request with its retries, 0 for none
+	SessionTimeout Duration `yaml:"session_timeout" json:"session_timeout"` // Timeout for creating every session, 0 for none
+}
+
+// Duration is a time.Duration written as a string such as "30s" in config files
+type Duration time.Duration
+
+// UnmarshalText parses a duration string
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText formats the duration as a string
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// DefaultConfig returns the settings used for anything a config file or the
+// environment leaves out
+func DefaultConfig() Config {
+	return Config{
+		Headless:       true,
+		SleepAfter:     3,
+		LogLevel:       "warn",
+		MaxRetries:     2,
+		RequestTimeout: Duration(30 * time.Second),
+		SessionTimeout: Duration(2 * time.Minute),
+	}
+}
+
+// LoadConfig returns the default config overridden by the file at path, if
+// path is not empty, and then by TTSCRAPE_* environment variables. Files
+// ending in .json are read as JSON and anything else as YAML.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	if path != "" {
+		if err := cfg.LoadFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	if err := cfg.LoadEnv(); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// LoadFile overrides the config with the settings in a YAML or JSON file.
+// Unknown keys are rejected so typos do not go unnoticed.
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(c)
+		if errors.Is(err, io.EOF) {
+			err = nil // An empty file changes nothing
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return nil
+}
+
+// envVar binds an environment variable to a config field
+type envVar struct {
+	name  string
+	apply func(c *Config, value string) error
+}
+
+// envVars are read by LoadEnv. Lists are comma-separated.
+var envVars = []envVar{
+	{"TTSCRAPE_MS_TOKENS", func(c *Config, v string) error { c.MsTokens = splitList(v); return nil }},
+	{"TTSCRAPE_MS_TOKEN_FILE", func(c *Config, v string) error { c.MsTokenFile = v; return nil }},
+	{"TTSCRAPE_PROXIES", func(c *Config, v string) error { c.Proxies = splitList(v); return nil }},
+	{"TTSCRAPE_SESSIONS", func(c *Config, v string) error { return parseEnvInt(v, &c.Sessions) }},
+	{"TTSCRAPE_HEADLESS", func(c *Config, v string) error { return parseEnvBool(v, &c.Headless) }},
+	{"TTSCRAPE_BROWSER_FREE", func(c *Config, v string) error { return parseEnvBool(v, &c.BrowserFree) }},
+	{"TTSCRAPE_BROWSER_PATH", func(c *Config, v string) error { c.BrowserPath = v; return nil }},
+	{"TTSCRAPE_SLEEP_AFTER", func(c *Config, v string) error { return parseEnvInt(v, &c.SleepAfter) }},
+	{"TTSCRAPE_BASE_URL", func(c *Config, v string) error { c.BaseURL = v; return nil }},
+	{"TTSCRAPE_PAGE_FALLBACK", func(c *Config, v string) error { return parseEnvBool(v, &c.PageFallback) }},
+	{"TTSCRAPE_LOG_LEVEL", func(c *Config, v string) error { c.LogLevel = v; return nil }},
+	{"TTSCRAPE_MAX_RETRIES", func(c *Config, v string) error { return parseEnvInt(v, &c.MaxRetries) }},
+	{"TTSCRAPE_RATE_LIMIT", func(c *Config, v string) error {
+		limit, err := strconv.ParseFloat(v, 64)
+		c.RateLimit = limit
+		return err
+	}},
+	{"TTSCRAPE_RATE_BURST", func(c *Config, v string) error { return parseEnvInt(v, &c.RateBurst) }},
+	{"TTSCRAPE_REQUEST_TIMEOUT", func(c *Config, v string) error { return c.RequestTimeout.UnmarshalText([]byte(v)) }},
+	{"TTSCRAPE_SESSION_TIMEOUT", func(c *Config, v string) error { return c.SessionTimeout.UnmarshalText([]byte(v)) }},
+}
+
+// LoadEnv overrides the config with the TTSCRAPE_* environment variables that
+// are set. The ms_token variable read by TikTokApi is used when no msToken is
+// configured any other way.
+func (c *Config) LoadEnv() error {
+	for _, env := range envVars {
+		value, ok := os.LookupEnv(env.name)
+		if !ok {
+			continue
+		}
+		if err := env.apply(c, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("%s: %w", env.name, err)
+		}
+	}
+
+	if len(c.MsTokens) == 0 && c.MsTokenFile == "" {
+		if token := os.Getenv("ms_token"); token != "" {
+			c.MsTokens = []string{token}
+		}
+	}
+	return nil
+}
+
+// Validate reports every invalid setting
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Sessions >= 0, "sessions must not be negative")
+	check(c.SleepAfter >= 0, "sleep_after must not be negative")
+	check(c.MaxRetries >= 0, "max_retries must not be negative")
+	check(c.RateLimit >= 0, "rate_limit must not be negative")
+	check(c.RateBurst >= 0, "rate_burst must not be negative")
+	check(c.RequestTimeout >= 0, "request_timeout must not be negative")
+	check(c.SessionTimeout >= 0, "session_timeout must not be negative")
+
+	if _, err := ParseLogLevel(c.LogLevel); c.LogLevel != "" && err != nil {
+		errs = append(errs, err)
+	}
+	if c.BaseURL != "" {
+		parsed, err := url.Parse(c.BaseURL)
+		check(err == nil && parsed.Scheme != "" && parsed.Host != "", "invalid base_url %q", c.BaseURL)
+	}
+	for _, proxy := range c.Proxies {
+		parsed, err := url.Parse(proxy)
+		check(err == nil && parsed.Scheme != "" && parsed.Host != "", "invalid proxy URL %q", proxy)
+	}
+	for i, token := range c.MsTokens {
+		check(strings.TrimSpace(token) != "", "ms_tokens[%d] is empty", i)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Tokens returns the configured msTokens followed by those in MsTokenFile.
+// Blank lines and lines starting with # are skipped.
+func (c Config) Tokens() ([]string, error) {
+	tokens := append([]string(nil), c.MsTokens...)
+	if c.MsTokenFile == "" {
+		return tokens, nil
+	}
+
+	file, err := os.Open(c.MsTokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("read token file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if token := strings.TrimSpace(scanner.Text()); token != "" && !strings.HasPrefix(token, "#") {
+			tokens = append(tokens, token)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read token file: %w", err)
+	}
+	return tokens, nil
+}
+
+// NewTikTokAPIFromConfig validates cfg and returns a TikTokAPI configured from
+// it with its sessions created. The caller must call Close on the returned API.
+func NewTikTokAPIFromConfig(ctx context.Context, cfg Config) (*TikTokAPI, error) {
+	api, err := cfg.NewAPI()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.StartSessions(ctx, api); err != nil {
+		api.Close()
+		return nil, err
+	}
+	return api, nil
+}
+
+// NewAPI validates the config and returns a TikTokAPI configured from it,
+// without sessions. Use it with StartSessions when sessions are created later.
+func (c Config) NewAPI() (*TikTokAPI, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	logLevel := 0
+	if c.LogLevel != "" {
+		logLevel, _ = ParseLogLevel(c.LogLevel)
+	}
+
+	api := NewTikTokAPI(logLevel)
+	api.SetHeadless(c.Headless)
+	api.SetBrowserFree(c.BrowserFree)
+	api.SetBrowserPath(c.BrowserPath)
+	api.SetPageFallback(c.PageFallback)
+	if c.BaseURL != "" {
+		api.SetBaseURL(c.BaseURL)
+	}
+	api.SetProxies(c.Proxies)
+	api.SetMaxRetries(c.MaxRetries)
+	api.SetRateLimit(c.RateLimit, c.RateBurst)
+	api.SetRequestTimeout(time.Duration(c.RequestTimeout))
+	return api, nil
+}
+
+// StartSessions creates the configured sessions on api, giving up after
+// SessionTimeout. The timeout and ctx only bound setup: the sessions, and their
+// browsers, last until api is closed. Sessions defaults to one per msToken, and
+// at least one.
+func (c Config) StartSessions(ctx context.Context, api *TikTokAPI) error {
+	tokens, err := c.Tokens()
+	if err != nil {
+		return err
+	}
+
+	sessions := c.Sessions
+	if sessions == 0 {
+		sessions = max(len(tokens), 1)
+	}
+
+	if c.SessionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.SessionTimeout))
+		defer cancel()
+	}
+
+	if err := api.CreateSessions(ctx, sessions, tokens, c.SleepAfter, "chromium"); err != nil {
+		return err
+	}
+	if api.SessionCount() == 0 {
+		return errors.New("no sessions were created")
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping blank entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseEnvInt parses an integer environment value into dst
+func parseEnvInt(value string, dst *int) error {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", value)
+	}
+	*dst = parsed
+	return nil
+}
+
+// parseEnvBool parses a boolean environment value such as "true" or "0" into dst
+func parseEnvBool(value string, dst *bool) error {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid boolean %q", value)
+	}
+	*dst = parsed
+	return nil
+}
@@ -0,0 +1,172 @@
+package ttscrape_go_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// writeFile writes content to name in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := ttscrape_go.DefaultConfig().Validate(); err != nil {
+		t.Fatalf("default config: %v", err)
+	}
+
+	cfg := ttscrape_go.DefaultConfig()
+	cfg.Sessions = -1
+	cfg.RateLimit = -2
+	cfg.LogLevel = "loud"
+	cfg.BaseURL = "not a url"
+	cfg.Proxies = []string{"http://proxy:8080", "proxy"}
+	cfg.MsTokens = []string{"token", " "}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("invalid config passed validation")
+	}
+	// Every problem is reported, not just the first
+	for _, want := range []string{"sessions", "rate_limit", "loud", "base_url", `"proxy"`, "ms_tokens[1]"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeFile(t, "ttscrape.yaml", `
+ms_tokens: [file-token]
+sessions: 2
+rate_limit: 1.5
+request_timeout: 10s
+`)
+	t.Setenv("TTSCRAPE_SESSIONS", "3")
+	t.Setenv("TTSCRAPE_PROXIES", "http://a:1, http://b:2")
+
+	cfg, err := ttscrape_go.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Sessions != 3 || cfg.RateLimit != 1.5 || time.Duration(cfg.RequestTimeout) != 10*time.Second {
+		t.Errorf("got sessions %d, rate limit %v, request timeout %v", cfg.Sessions, cfg.RateLimit, cfg.RequestTimeout)
+	}
+	if !slices.Equal(cfg.MsTokens, []string{"file-token"}) || !slices.Equal(cfg.Proxies, []string{"http://a:1", "http://b:2"}) {
+		t.Errorf("got tokens %v, proxies %v", cfg.MsTokens, cfg.Proxies)
+	}
+	if cfg.MaxRetries != ttscrape_go.DefaultConfig().MaxRetries {
+		t.Errorf("max retries %d, want the default", cfg.MaxRetries)
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"typo.yaml":     "rate_limt: 1\n",
+		"typo.json":     `{"sessions": 1, "proxy": "x"}`,
+		"timeout.yaml":  "request_timeout: soon\n",
+		"negative.yaml": "max_retries: -1\n",
+	} {
+		if _, err := ttscrape_go.LoadConfig(writeFile(t, name, content)); err == nil {
+			t.Errorf("%s: LoadConfig succeeded", name)
+		}
+	}
+
+	t.Setenv("TTSCRAPE_HEADLESS", "maybe")
+	if _, err := ttscrape_go.LoadConfig(""); err == nil || !strings.Contains(err.Error(), "TTSCRAPE_HEADLESS") {
+		t.Errorf("LoadConfig with an invalid TTSCRAPE_HEADLESS returned %v", err)
+	}
+}
+
+func TestConfigTokens(t *testing.T) {
+	t.Setenv("ms_token", "env-token")
+
+	cfg := ttscrape_go.DefaultConfig()
+	if err := cfg.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cfg.MsTokens, []string{"env-token"}) {
+		t.Errorf("ms_token gave tokens %v", cfg.MsTokens)
+	}
+
+	cfg.MsTokens = []string{"a"}
+	cfg.MsTokenFile = writeFile(t, "tokens.txt", "b\n\n# comment\n  c  \n")
+	tokens, err := cfg.Tokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tokens, []string{"a", "b", "c"}) {
+		t.Errorf("Tokens = %v, want [a b c]", tokens)
+	}
+}
+
+func TestStartSessionsTimeout(t *testing.T) {
+	srv := ttscrapetest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddSound("1", ttscrapetest.SyntheticFixture("1", 1))
+
+	cfg := ttscrape_go.DefaultConfig()
+	cfg.MsTokens = []string{"token"}
+	cfg.BrowserFree = true
+	cfg.BaseURL = srv.URL
+	cfg.SessionTimeout = ttscrape_go.Duration(50 * time.Millisecond)
+	api, err := ttscrape_go.NewTikTokAPIFromConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+
+	// The session timeout only bounds setup
+	time.Sleep(100 * time.Millisecond)
+	if _, err := api.Sound("1").Info(context.Background()); err != nil {
+		t.Errorf("Info after the session timeout: %v", err)
+	}
+}
+
+func TestStartSessionsBrowser(t *testing.T) {
+	var browserPath string
+	for _, name := range []string{"chromium", "chromium-browser", "google-chrome", "headless-shell"} {
+		if path, err := exec.LookPath(name); err == nil {
+			browserPath = path
+			break
+		}
+	}
+	if browserPath == "" {
+		t.Skip("no Chrome or Chromium found")
+	}
+	srv := ttscrapetest.NewServer()
+	t.Cleanup(srv.Close)
+
+	cfg := ttscrape_go.DefaultConfig()
+	cfg.Sessions = 1
+	cfg.Headless = true
+	cfg.BrowserPath = browserPath
+	cfg.BaseURL = srv.URL
+	cfg.SessionTimeout = ttscrape_go.Duration(30 * time.Second)
+	api, err := ttscrape_go.NewTikTokAPIFromConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+
+	// Returning from setup must not close the browser
+	for i, session := range api.Sessions() {
+		if session != nil && !session.BrowserFree && session.Context.Err() != nil {
+			t.Errorf("session %d browser closed after setup: %v", i, session.Context.Err())
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package ttscrape_go
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CrawlState is what an incremental crawl remembers between runs. The newest
+// video only moves once a crawl has caught up with it; a crawl that is cut
+// short records where to resume and the newest video it sent as pending.
+type CrawlState struct {
+	NewestCreateTime  int64     `json:"newestCreateTime"`            // createTime of the newest video caught up with
+	NewestID          string    `json:"newestId"`                    // ID of the newest video caught up with
+	LastCursor        int       `json:"lastCursor"`                  // Cursor to resume an unfinished crawl at, 0 if it finished
+	PendingCreateTime int64     `json:"pendingCreateTime,omitempty"` // createTime of the newest video sent by an unfinished crawl
+	PendingID         string    `json:"pendingId,omitempty"`         // ID of the newest video sent by an unfinished crawl
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// CrawlStateStore loads and saves crawl state by key. Implementations must be
+// safe for concurrent use.
+type CrawlStateStore interface {
+	LoadCrawlState(ctx context.Context, key string) (CrawlState, bool, error)
+	SaveCrawlState(ctx context.Context, key string, state CrawlState) error
+}
+
+// MemoryCrawlState keeps crawl state in memory for the life of the process
+type MemoryCrawlState struct {
+	mu     sync.Mutex
+	states map[string]CrawlState
+}
+
+// NewMemoryCrawlState creates an empty in-memory crawl state store
+func NewMemoryCrawlState() *MemoryCrawlState {
+	return &MemoryCrawlState{states: make(map[string]CrawlState)}
+}
+
+// LoadCrawlState returns the state saved under key
+func (m *MemoryCrawlState) LoadCrawlState(ctx context.Context, key string) (CrawlState, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.states[key]
+	return state, ok, nil
+}
+
+// SaveCrawlState saves the state under key
+func (m *MemoryCrawlState) SaveCrawlState(ctx context.Context, key string, state CrawlState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.states[key] = state
+	return nil
+}
+
+// FileCrawlState keeps crawl state for every key in a single JSON file
+type FileCrawlState struct {
+	path string
+
+	mu     sync.Mutex
+	states map[string]CrawlState
+}
+
+// NewFileCrawlState opens the crawl state file at path, which is created on the first save
+func NewFileCrawlState(path string) (*FileCrawlState, error) {
+	f := &FileCrawlState{path: path, states: make(map[string]CrawlState)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read crawl state: %w", err)
+	}
+	if err := json.Unmarshal(data, &f.states); err != nil {
+		return nil, fmt.Errorf("parse crawl state: %w", err)
+	}
+	return f, nil
+}
+
+// LoadCrawlState returns the state saved under key
+func (f *FileCrawlState) LoadCrawlState(ctx context.Context, key string) (CrawlState, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	state, ok := f.states[key]
+	return state, ok, nil
+}
+
+// SaveCrawlState saves the state under key and rewrites the file
+func (f *FileCrawlState) SaveCrawlState(ctx context.Context, key string, state CrawlState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.states[key] = state
+	data, err := json.MarshalIndent(f.states, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated file
+	if dir := filepath.Dir(f.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("save crawl state: %w", err)
+		}
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("save crawl state: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("save crawl state: %w", err)
+	}
+	return nil
+}
+
+// soundCrawlKey is the crawl state key of a sound
+func soundCrawlKey(id string) string {
+	return "sound:" + id
+}
+
+// NewVideos retrieves up to count videos using this sound that are newer than
+// any seen by a previous call with the same state store. Paging stops at the
+// first page without a new video, so known videos are not paged through again,
+// and only then does the newest video seen count as known. A crawl cut short by
+// count, a failed request or Shutdown saves the cursor of the first video it
+// did not send, and the next call resumes there rather than skipping the videos
+// in between or sending any again. A sound crawled
+// for the first time is paged back to its oldest video over as many calls as it
+// takes. The state is saved once the crawl ends, after the last video has been
+// sent on the channel. A failure to save it is logged and, unless paging had
+// already failed, reported to the WithPagingError handler.
+func (s *Sound) NewVideos(ctx context.Context, state CrawlStateStore, count int, options ...RequestOption) (chan map[string]interface{}, error) {
+	// Get the API reference
+	api := s.API
+	if api == nil {
+		return nil, errInvalidAPI
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive")
+	}
+	if state == nil {
+		return nil, fmt.Errorf("crawl state store is required")
+	}
+
+	opts := newRequestOptions(options)
+
+	key := soundCrawlKey(s.ID)
+	previous, _, err := state.LoadCrawlState(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("load crawl state: %w", err)
+	}
+
+	// Create a channel to send videos
+	videos := make(chan map[string]interface{}, count)
+
+	// Register the goroutine so Shutdown can stop it and wait for it
+	stop, done, err := api.beginPaging()
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a goroutine to fetch videos
+	go func() {
+		defer done()
+		defer close(videos)
+
+		// Resume an unfinished crawl where it stopped, remembering the newest
+		// video it had sent
+		newestCreateTime, newestID := previous.NewestCreateTime, previous.NewestID
+		if newerVideo(previous.PendingCreateTime, previous.PendingID, newestCreateTime, newestID) {
+			newestCreateTime, newestID = previous.PendingCreateTime, previous.PendingID
+		}
+		currentCount := 0
+		currentCursor := previous.LastCursor
+		resumeCursor := currentCursor
+		defer func() {
+			api.observePaging("sound", currentCount)
+		}()
+
+		// Save the state once the crawl ends. Only a finished crawl moves the
+		// newest video; an unfinished one keeps it and records where to resume.
+		finished, failed := false, false
+		defer func() {
+			next := CrawlState{
+				NewestCreateTime: newestCreateTime,
+				NewestID:         newestID,
+				UpdatedAt:        time.Now(),
+			}
+			if !finished {
+				next = CrawlState{
+					NewestCreateTime:  previous.NewestCreateTime,
+					NewestID:          previous.NewestID,
+					LastCursor:        resumeCursor,
+					PendingCreateTime: newestCreateTime,
+					PendingID:         newestID,
+					UpdatedAt:         next.UpdatedAt,
+				}
+			}
+
+			// Save even if ctx was cancelled, so the next crawl can resume
+			if err := state.SaveCrawlState(context.WithoutCancel(ctx), key, next); err != nil {
+				api.logger().Error("crawl state not saved", "key", key, "error", err)
+				if !failed {
+					opts.pagingFailed(fmt.Errorf("save crawl state: %w", err))
+				}
+			}
+		}()
+
+		for currentCount < count {
+			resumeCursor = currentCursor
+
+			// Set up URL parameters
+			params := map[string]string{
+				"musicID": s.ID,
+				"count":   fmt.Sprintf("%d", 30), // Max count per request
+				"cursor":  fmt.Sprintf("%d", currentCursor),
+			}
+
+			// Make the request
+			resp, err := api.makeRequest(ctx, opts.call("/api/music/item_list/", params))
+			if err := pageError(resp, err); err != nil {
+				failed = true
+				opts.pagingFailed(err)
+				return
+			}
+
+			// Extract videos
+			itemList, ok := resp["itemList"].([]interface{})
+			api.logger().Debug("page fetched", "entity", "sound", "cursor", currentCursor, "items", len(itemList))
+			if !ok || len(itemList) == 0 {
+				finished = true
+				return
+			}
+
+			// Send only videos newer than the previous crawl's newest
+			newOnPage := 0
+			for i, item := range itemList {
+				videoMap, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				createTime, id := videoCreateTime(videoMap), videoID(videoMap)
+				if !newerVideo(createTime, id, previous.NewestCreateTime, previous.NewestID) {
+					continue
+				}
+				newOnPage++
+
+				// Stop at count, leaving the rest of the page for the next crawl
+				if currentCount >= count {
+					resumeCursor = currentCursor + i
+					return
+				}
+
+				select {
+				case videos <- videoMap:
+				case <-stop:
+					failed = true
+					resumeCursor = currentCursor + i
+					opts.pagingFailed(ErrShutdown)
+					return
+				case <-ctx.Done():
+					failed = true
+					resumeCursor = currentCursor + i
+					opts.pagingFailed(ctx.Err())
+					return
+				}
+				currentCount++
+
+				if newerVideo(createTime, id, newestCreateTime, newestID) {
+					newestCreateTime, newestID = createTime, id
+				}
+			}
+
+			// Stop once a page holds only videos we already know about
+			if newOnPage == 0 {
+				finished = true
+				return
+			}
+
+			// Update cursor for next page
+			hasMore, ok := resp["hasMore"].(bool)
+			if !ok || !hasMore {
+				finished = true
+				return
+			}
+
+			nextCursor, ok := cursorFromResponse(resp)
+			if !ok {
+				finished = true
+				return
+			}
+
+			currentCursor = nextCursor
+		}
+	}()
+
+	return videos, nil
+}
+
+// videoCreateTime returns the createTime of an item struct, sent as a number or a string
+func videoCreateTime(item map[string]interface{}) int64 {
+	switch v := item["createTime"].(type) {
+	case float64:
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// videoID returns the ID of an item struct
+func videoID(item map[string]interface{}) string {
+	id, _ := item["id"].(string)
+	return id
+}
+
+// newerVideo reports whether a video is newer than the reference, comparing
+// createTime and then the numeric ID
+func newerVideo(createTime int64, id string, refCreateTime int64, refID string) bool {
+	if createTime != refCreateTime {
+		return createTime > refCreateTime
+	}
+	if len(id) != len(refID) {
+		return len(id) > len(refID)
+	}
+	return id > refID
+}
@@ -0,0 +1,169 @@
+package ttscrape_go_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// newestFirst returns the first videos of a synthetic sound, newest first as
+// TikTok lists them
+func newestFirst(videos int) ttscrapetest.Fixture {
+	fixture := ttscrapetest.SyntheticFixture("1", videos)
+	slices.Reverse(fixture.Videos)
+	return fixture
+}
+
+// crawl runs NewVideos to the end and returns the video IDs and the paging error
+func crawl(t *testing.T, api *ttscrape_go.TikTokAPI, state ttscrape_go.CrawlStateStore, count int) ([]string, error) {
+	t.Helper()
+	var pagingErr error
+	videos, err := api.Sound("1").NewVideos(context.Background(), state, count, ttscrape_go.WithPagingError(func(err error) { pagingErr = err }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for video := range videos {
+		ids = append(ids, video["id"].(string))
+	}
+	return ids, pagingErr
+}
+
+func TestNewVideos(t *testing.T) {
+	srv := ttscrapetest.NewServer()
+	defer srv.Close()
+	srv.SetPageSize(10)
+	srv.AddSound("1", newestFirst(60))
+
+	api, err := srv.NewAPI(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+	state := ttscrape_go.NewMemoryCrawlState()
+	ctx := context.Background()
+
+	// The first crawl is cut short by count in the third page
+	ids, err := crawl(t, api, state, 25)
+	if err != nil || len(ids) != 25 {
+		t.Fatalf("first crawl: got %d videos, error %v", len(ids), err)
+	}
+	saved, _, _ := state.LoadCrawlState(ctx, "sound:1")
+	if saved.NewestID != "" || saved.LastCursor != 25 || saved.PendingID != ids[0] {
+		t.Fatalf("state after an unfinished crawl: %+v", saved)
+	}
+	first := ids
+
+	// The next crawl resumes at the first unsent video and pages back to the
+	// oldest one, sending every video exactly once between them
+	ids, err = crawl(t, api, state, 100)
+	if err != nil || len(ids) != 35 {
+		t.Fatalf("resumed crawl: got %d videos, error %v", len(ids), err)
+	}
+	if all := append(slices.Clone(first), ids...); len(slices.Compact(slices.Sorted(slices.Values(all)))) != 60 {
+		t.Errorf("the two crawls sent %d videos but not all 60 distinct ones", len(all))
+	}
+	saved, _, _ = state.LoadCrawlState(ctx, "sound:1")
+	newest := newestFirst(60).Videos[0]["id"].(string)
+	if saved.NewestID != newest || saved.LastCursor != 0 || saved.PendingID != "" {
+		t.Fatalf("state after a finished crawl: %+v", saved)
+	}
+
+	// Nothing is new until more videos are posted
+	if ids, err = crawl(t, api, state, 100); err != nil || len(ids) != 0 {
+		t.Fatalf("up-to-date crawl: got %d videos, error %v", len(ids), err)
+	}
+	srv.AddSound("1", newestFirst(75))
+	if ids, err = crawl(t, api, state, 100); err != nil || len(ids) != 15 {
+		t.Fatalf("crawl after new videos: got %d videos, error %v", len(ids), err)
+	}
+}
+
+func TestNewVideosFailedRequest(t *testing.T) {
+	srv := ttscrapetest.NewServer()
+	defer srv.Close()
+	srv.SetPageSize(10)
+	srv.AddSound("1", newestFirst(30))
+
+	api, err := srv.NewAPI(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+	state := ttscrape_go.NewMemoryCrawlState()
+
+	if ids, err := crawl(t, api, state, 100); err != nil || len(ids) != 30 {
+		t.Fatalf("first crawl: got %d videos, error %v", len(ids), err)
+	}
+
+	// A failed request leaves the newest video where it was
+	srv.AddSound("1", newestFirst(50))
+	srv.SetFault(ttscrapetest.PathMusicItemList, ttscrapetest.Fault{Captcha: true, Times: 1})
+	if ids, err := crawl(t, api, state, 100); err == nil || len(ids) != 0 {
+		t.Fatalf("failed crawl: got %d videos, error %v", len(ids), err)
+	}
+	if ids, err := crawl(t, api, state, 100); err != nil || len(ids) != 20 {
+		t.Fatalf("crawl after the failure: got %d videos, error %v", len(ids), err)
+	}
+}
+
+// failingState is a CrawlStateStore that cannot save
+type failingState struct {
+	*ttscrape_go.MemoryCrawlState
+}
+
+func (failingState) SaveCrawlState(ctx context.Context, key string, state ttscrape_go.CrawlState) error {
+	return errors.New("disk full")
+}
+
+func TestNewVideosSaveError(t *testing.T) {
+	srv := ttscrapetest.NewServer()
+	defer srv.Close()
+	srv.AddSound("1", newestFirst(5))
+
+	api, err := srv.NewAPI(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+
+	ids, err := crawl(t, api, failingState{ttscrape_go.NewMemoryCrawlState()}, 100)
+	if len(ids) != 5 {
+		t.Errorf("got %d videos, want 5", len(ids))
+	}
+	if err == nil {
+		t.Fatal("save error not reported")
+	}
+}
+
+func TestFileCrawlState(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state", "crawl.json")
+
+	state, err := ttscrape_go.NewFileCrawlState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ttscrape_go.CrawlState{NewestCreateTime: 1700000000, NewestID: "7300000000000000001", LastCursor: 30}
+	if err := state.SaveCrawlState(ctx, "sound:1", want); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reopened file holds the saved state
+	state, err = ttscrape_go.NewFileCrawlState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := state.LoadCrawlState(ctx, "sound:1")
+	if err != nil || !ok || got != want {
+		t.Errorf("got %+v, %v, %v; want %+v", got, ok, err, want)
+	}
+	if _, ok, _ := state.LoadCrawlState(ctx, "sound:2"); ok {
+		t.Error("found state for an unknown key")
+	}
+}
@@ -0,0 +1,497 @@
+package ttscrape_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxDownloadAttempts is how many times each download URL is tried before giving up
+const maxDownloadAttempts = 3
+
+// ErrNoRendition is returned when no rendition matches a RenditionSelector
+var ErrNoRendition = errors.New("no matching rendition")
+
+// ErrSizeMismatch is returned when a download does not match its advertised size
+var ErrSizeMismatch = errors.New("downloaded size does not match expected size")
+
+// cookieDomains are the CDN domains outside tiktok.com that are sent the
+// session's cookies with media downloads
+var cookieDomains = []string{
+	"tiktokcdn.com",
+	"tiktokcdn-us.com",
+	"tiktokcdn-eu.com",
+	"tiktokv.com",
+	"tiktokv.us",
+	"ibytedtos.com",
+	"byteoversea.com",
+}
+
+// Rendition is a single encoding of a video listed in video.bitrateInfo
+type Rendition struct {
+	GearName    string
+	QualityType int
+	CodecType   string
+	Bitrate     int
+	DataSize    int64
+	Width       int
+	Height      int
+	URLs        []string
+}
+
+// ProgressFunc is called as a download advances with the bytes written so far
+// and the total size, which is 0 when unknown
+type ProgressFunc func(written, total int64)
+
+// RenditionSelector picks the rendition to download from the available ones
+type RenditionSelector func([]Rendition) (Rendition, bool)
+
+// HighestBitrate selects the rendition with the highest bitrate
+func HighestBitrate(renditions []Rendition) (Rendition, bool) {
+	best := -1
+	for i, r := range renditions {
+		if best == -1 || r.Bitrate > renditions[best].Bitrate {
+			best = i
+		}
+	}
+	if best == -1 {
+		return Rendition{}, false
+	}
+	return renditions[best], true
+}
+
+// LowestBitrate selects the rendition with the lowest bitrate
+func LowestBitrate(renditions []Rendition) (Rendition, bool) {
+	best := -1
+	for i, r := range renditions {
+		if best == -1 || r.Bitrate < renditions[best].Bitrate {
+			best = i
+		}
+	}
+	if best == -1 {
+		return Rendition{}, false
+	}
+	return renditions[best], true
+}
+
+// WithCodec restricts a selector to renditions using the given codec (e.g. "h264")
+func WithCodec(codec string, next RenditionSelector) RenditionSelector {
+	return func(renditions []Rendition) (Rendition, bool) {
+		filtered := make([]Rendition, 0, len(renditions))
+		for _, r := range renditions {
+			if strings.EqualFold(r.CodecType, codec) {
+				filtered = append(filtered, r)
+			}
+		}
+		return next(filtered)
+	}
+}
+
+// WithMaxSize restricts a selector to renditions no larger than maxBytes
+func WithMaxSize(maxBytes int64, next RenditionSelector) RenditionSelector {
+	return func(renditions []Rendition) (Rendition, bool) {
+		filtered := make([]Rendition, 0, len(renditions))
+		for _, r := range renditions {
+			if r.DataSize > 0 && r.DataSize <= maxBytes {
+				filtered = append(filtered, r)
+			}
+		}
+		return next(filtered)
+	}
+}
+
+// Renditions returns the encodings listed in the video's bitrateInfo
+func (v *Video) Renditions() []Rendition {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.renditions()
+}
+
+// renditions parses the video's bitrateInfo, the caller must hold v.mu
+func (v *Video) renditions() []Rendition {
+	videoInfo, ok := v.AsDict["video"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	bitrateInfo, _ := videoInfo["bitrateInfo"].([]interface{})
+	renditions := make([]Rendition, 0, len(bitrateInfo))
+	for _, entry := range bitrateInfo {
+		info, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		r := Rendition{}
+		r.GearName, _ = info["GearName"].(string)
+		r.CodecType, _ = info["CodecType"].(string)
+		if qualityType, ok := info["QualityType"].(float64); ok {
+			r.QualityType = int(qualityType)
+		}
+		if bitrate, ok := info["Bitrate"].(float64); ok {
+			r.Bitrate = int(bitrate)
+		}
+
+		playAddr, ok := info["PlayAddr"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if dataSize, ok := playAddr["DataSize"].(float64); ok {
+			r.DataSize = int64(dataSize)
+		}
+		if width, ok := playAddr["Width"].(float64); ok {
+			r.Width = int(width)
+		}
+		if height, ok := playAddr["Height"].(float64); ok {
+			r.Height = int(height)
+		}
+		urlList, _ := playAddr["UrlList"].([]interface{})
+		for _, u := range urlList {
+			if s, ok := u.(string); ok && s != "" {
+				r.URLs = append(r.URLs, s)
+			}
+		}
+
+		if len(r.URLs) > 0 {
+			renditions = append(renditions, r)
+		}
+	}
+
+	// Older items only carry a single play address
+	if len(renditions) == 0 {
+		if playAddr, ok := videoInfo["playAddr"].(string); ok && playAddr != "" {
+			r := Rendition{URLs: []string{playAddr}}
+			r.CodecType, _ = videoInfo["codecType"].(string)
+			if bitrate, ok := videoInfo["bitrate"].(float64); ok {
+				r.Bitrate = int(bitrate)
+			}
+			renditions = append(renditions, r)
+		}
+	}
+
+	return renditions
+}
+
+// Download writes the video file to w, using selector to choose the rendition.
+// A nil selector downloads the highest bitrate rendition. WithSession and
+// WithProgress apply to the download itself; every option applies to the info
+// request made when the video has not been fetched yet.
+func (v *Video) Download(ctx context.Context, w io.Writer, selector RenditionSelector, options ...RequestOption) (int64, error) {
+	opts := newRequestOptions(options)
+
+	v.mu.Lock()
+	needsInfo := v.AsDict == nil
+	v.mu.Unlock()
+
+	// Fetch the item first if this video was created from an ID only
+	if needsInfo {
+		if _, err := v.Info(ctx, options...); err != nil {
+			return 0, fmt.Errorf("fetch video info: %w", err)
+		}
+	}
+
+	// Get the API reference
+	api := v.API
+	if api == nil {
+		return 0, errInvalidAPI
+	}
+
+	if selector == nil {
+		selector = HighestBitrate
+	}
+
+	rendition, ok := selector(v.Renditions())
+	if !ok {
+		return 0, ErrNoRendition
+	}
+
+	req := downloadRequest{
+		URLs:         rendition.URLs,
+		Size:         rendition.DataSize,
+		SessionIndex: opts.SessionIndex,
+		Progress:     opts.Progress,
+	}
+
+	return api.download(ctx, req, w)
+}
+
+// DownloadAudio writes the sound's audio track to w. WithSession and
+// WithProgress apply to the download itself; every option applies to the info
+// request made when the sound has not been fetched yet.
+func (s *Sound) DownloadAudio(ctx context.Context, w io.Writer, options ...RequestOption) (int64, error) {
+	opts := newRequestOptions(options)
+
+	s.mu.Lock()
+	needsInfo := s.AsDict == nil
+	s.mu.Unlock()
+
+	// Fetch the sound first since the play URL comes from music/detail
+	if needsInfo {
+		if _, err := s.Info(ctx, options...); err != nil {
+			return 0, fmt.Errorf("fetch sound info: %w", err)
+		}
+	}
+
+	// Get the API reference
+	api := s.API
+	if api == nil {
+		return 0, errInvalidAPI
+	}
+
+	playURL := s.PlayURL()
+	if playURL == "" {
+		return 0, fmt.Errorf("sound %s has no play URL", s.ID)
+	}
+
+	req := downloadRequest{
+		URLs:         []string{playURL},
+		SessionIndex: opts.SessionIndex,
+		Progress:     opts.Progress,
+	}
+
+	return api.download(ctx, req, w)
+}
+
+// PlayURL returns the URL of the sound's audio file from the last Info call
+func (s *Sound) PlayURL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	musicInfo, ok := s.AsDict["musicInfo"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	music, ok := musicInfo["music"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	playURL, _ := music["playUrl"].(string)
+	return playURL
+}
+
+// downloadRequest describes a media file to fetch
+type downloadRequest struct {
+	URLs         []string // Mirrors of the same file, tried in order
+	Size         int64    // Expected size in bytes, or 0 if unknown
+	SessionIndex int
+	Progress     ProgressFunc
+}
+
+// download fetches a media file with the session's headers and cookies,
+// resuming with Range requests when a transfer is interrupted
+func (api *TikTokAPI) download(ctx context.Context, req downloadRequest, w io.Writer) (int64, error) {
+	// Register the download so Shutdown waits for it
+	done, err := api.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	ctx, cancel := api.abortable(ctx)
+	defer cancel()
+
+	session, err := api.session(req.SessionIndex)
+	if err != nil {
+		return 0, err
+	}
+	if len(req.URLs) == 0 {
+		return 0, fmt.Errorf("no download URL")
+	}
+
+	// Failed writes are not retried: the next attempt would resume at the wrong offset
+	w = destWriter{w: w}
+
+	// Report progress as bytes reach the writer
+	if req.Progress != nil {
+		w = &progressWriter{w: w, total: &req.Size, progress: req.Progress}
+	}
+
+	var written int64
+	var lastErr error
+	for attempt := 0; attempt < maxDownloadAttempts*len(req.URLs); attempt++ {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		// Rotate through mirrors, resuming from what was already written
+		urlStr := req.URLs[attempt%len(req.URLs)]
+		n, total, err := api.downloadAttempt(ctx, session, urlStr, w, written)
+		written += n
+		if req.Size == 0 && total > 0 {
+			req.Size = total
+		}
+		var writeErr *writeError
+		if errors.As(err, &writeErr) {
+			return written, fmt.Errorf("download failed after %d bytes: %w", written, writeErr)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		// A clean EOF before the advertised size means the transfer was cut short
+		if req.Size > 0 && written < req.Size {
+			lastErr = io.ErrUnexpectedEOF
+			continue
+		}
+
+		lastErr = nil
+		break
+	}
+	if lastErr != nil {
+		return written, fmt.Errorf("download failed after %d bytes: %w", written, lastErr)
+	}
+
+	// Verify the size
+	if req.Size > 0 && written != req.Size {
+		return written, fmt.Errorf("%w: got %d bytes, want %d", ErrSizeMismatch, written, req.Size)
+	}
+
+	return written, nil
+}
+
+// downloadAttempt makes a single request for a media file starting at offset.
+// It returns the bytes written and the full file size if the server reported it.
+func (api *TikTokAPI) downloadAttempt(ctx context.Context, session *TikTokSession, urlStr string, w io.Writer, offset int64) (int64, int64, error) {
+	// Create request
+	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Set headers
+	for k, v := range session.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", "*/*")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	// The jar only sends tiktok.com cookies to tiktok.com hosts, but the CDN wants them too
+	if session.Jar != nil && cookieDomain(req.URL.Hostname()) {
+		if baseURL, err := url.Parse(defaultBaseURL); err == nil {
+			for _, c := range session.Jar.Cookies(baseURL) {
+				req.AddCookie(c)
+			}
+		}
+	}
+
+	// Make request
+	client := api.sessionClient(session)
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	var total int64
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		// Appending a range that does not start where the file left off would corrupt it
+		var start int64
+		start, total = parseContentRange(resp.Header.Get("Content-Range"))
+		if start != offset {
+			return 0, 0, fmt.Errorf("server resumed at byte %d, want %d", start, offset)
+		}
+	case http.StatusOK:
+		total = resp.ContentLength
+		// The server ignored the Range header, so skip what was already written
+		if offset > 0 {
+			if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+				return 0, total, err
+			}
+		}
+	default:
+		return 0, 0, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	n, err := io.Copy(w, resp.Body)
+	return n, total, err
+}
+
+// parseContentRange reads the start and full size from a "bytes start-end/total"
+// header, returning a start of -1 if it is missing and a total of 0 if unknown
+func parseContentRange(contentRange string) (int64, int64) {
+	byteRange, size, ok := strings.Cut(strings.TrimPrefix(contentRange, "bytes "), "/")
+	if !ok {
+		return -1, 0
+	}
+	start := int64(-1)
+	if first, _, ok := strings.Cut(byteRange, "-"); ok {
+		if n, err := strconv.ParseInt(first, 10, 64); err == nil {
+			start = n
+		}
+	}
+	total, err := strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return start, 0
+	}
+	return start, total
+}
+
+// cookieDomain reports whether host is one of cookieDomains or a subdomain of one
+func cookieDomain(host string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range cookieDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeError is a failure of the download's destination writer
+type writeError struct {
+	err error
+}
+
+// Error returns the underlying error message
+func (e *writeError) Error() string {
+	return "write: " + e.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *writeError) Unwrap() error {
+	return e.err
+}
+
+// destWriter marks the errors of the destination writer so they are not
+// mistaken for network errors
+type destWriter struct {
+	w io.Writer
+}
+
+// Write writes to the underlying writer, wrapping any error in writeError
+func (d destWriter) Write(b []byte) (int, error) {
+	n, err := d.w.Write(b)
+	if err == nil && n < len(b) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		return n, &writeError{err: err}
+	}
+	return n, nil
+}
+
+// progressWriter reports the running byte count of a download
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    *int64 // Points at the request size, which may be learned mid-download
+	progress ProgressFunc
+}
+
+// Write writes to the underlying writer and reports progress
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.progress(p.written, *p.total)
+	return n, err
+}
@@ -0,0 +1,230 @@
+package ttscrape_go_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+)
+
+// media is the file served by the download stand-ins
+var media = bytes.Repeat([]byte("0123456789"), 100)
+
+// mediaServer serves media through respond, recording the Range header of each
+// request
+type mediaServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	ranges  []string
+	respond func(w http.ResponseWriter, r *http.Request, attempt int)
+}
+
+// newMediaServer starts a mediaServer, respond is given the 0-based request number
+func newMediaServer(t *testing.T, respond func(w http.ResponseWriter, r *http.Request, attempt int)) *mediaServer {
+	t.Helper()
+	m := &mediaServer{respond: respond}
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		attempt := len(m.ranges)
+		m.ranges = append(m.ranges, r.Header.Get("Range"))
+		m.mu.Unlock()
+		m.respond(w, r, attempt)
+	}))
+	t.Cleanup(m.Close)
+	return m
+}
+
+// Ranges returns the Range header of every request so far
+func (m *mediaServer) Ranges() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.ranges...)
+}
+
+// serveMedia serves media, honouring Range
+func serveMedia(w http.ResponseWriter, r *http.Request) {
+	http.ServeContent(w, r, "video.mp4", time.Time{}, bytes.NewReader(media))
+}
+
+// cutMedia announces the whole of media but drops the connection after n bytes
+func cutMedia(w http.ResponseWriter, n int) {
+	w.Header().Set("Content-Length", fmt.Sprint(len(media)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(media[:n])
+	w.(http.Flusher).Flush()
+	panic(http.ErrAbortHandler)
+}
+
+// mediaVideo returns a video with a single rendition of dataSize bytes
+func mediaVideo(api *ttscrape_go.TikTokAPI, dataSize int, urls ...string) *ttscrape_go.Video {
+	urlList := make([]interface{}, len(urls))
+	for i, u := range urls {
+		urlList[i] = u
+	}
+	return &ttscrape_go.Video{
+		API: api,
+		ID:  "1",
+		AsDict: map[string]interface{}{
+			"video": map[string]interface{}{
+				"bitrateInfo": []interface{}{
+					map[string]interface{}{
+						"Bitrate": float64(1000),
+						"PlayAddr": map[string]interface{}{
+							"DataSize": float64(dataSize),
+							"UrlList":  urlList,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	api, _ := newStandin(t)
+	m := newMediaServer(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		if attempt == 0 {
+			cutMedia(w, 400)
+		}
+		serveMedia(w, r)
+	})
+
+	var buf bytes.Buffer
+	n, err := mediaVideo(api, len(media), m.URL).Download(context.Background(), &buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(media)) || !bytes.Equal(buf.Bytes(), media) {
+		t.Errorf("downloaded %d bytes, want the %d of media", n, len(media))
+	}
+	if ranges := m.Ranges(); len(ranges) != 2 || ranges[0] != "" || ranges[1] != "bytes=400-" {
+		t.Errorf("got Range headers %q, want none then bytes=400-", ranges)
+	}
+}
+
+func TestDownloadMirrors(t *testing.T) {
+	api, _ := newStandin(t)
+	broken := newMediaServer(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	mirror := newMediaServer(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		serveMedia(w, r)
+	})
+
+	var buf bytes.Buffer
+	if _, err := mediaVideo(api, len(media), broken.URL, mirror.URL).Download(context.Background(), &buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), media) {
+		t.Error("download from the mirror does not match media")
+	}
+	if len(broken.Ranges()) != 1 || len(mirror.Ranges()) != 1 {
+		t.Errorf("got %d and %d requests, want one to each mirror", len(broken.Ranges()), len(mirror.Ranges()))
+	}
+}
+
+func TestDownloadRangeIgnored(t *testing.T) {
+	api, _ := newStandin(t)
+	m := newMediaServer(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		if attempt == 0 {
+			cutMedia(w, 400)
+		}
+		// Send the whole file again despite the Range header
+		w.Write(media)
+	})
+
+	var buf bytes.Buffer
+	if _, err := mediaVideo(api, len(media), m.URL).Download(context.Background(), &buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), media) {
+		t.Errorf("got %d bytes after a 200 to a Range request, want media", buf.Len())
+	}
+}
+
+func TestDownloadRangeMismatch(t *testing.T) {
+	api, _ := newStandin(t)
+	m := newMediaServer(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		switch attempt {
+		case 0:
+			cutMedia(w, 400)
+		case 1:
+			// A 206 for the wrong range must not be appended
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(media)-1, len(media)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(media)
+		default:
+			serveMedia(w, r)
+		}
+	})
+
+	var buf bytes.Buffer
+	if _, err := mediaVideo(api, len(media), m.URL).Download(context.Background(), &buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), media) {
+		t.Errorf("got %d bytes after a misplaced 206, want media", buf.Len())
+	}
+	if n := len(m.Ranges()); n != 3 {
+		t.Errorf("got %d requests, want 3", n)
+	}
+}
+
+func TestDownloadSize(t *testing.T) {
+	api, _ := newStandin(t)
+	m := newMediaServer(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		w.Write(media)
+	})
+
+	// More bytes than DataSize
+	_, err := mediaVideo(api, len(media)/2, m.URL).Download(context.Background(), io.Discard, nil)
+	if !errors.Is(err, ttscrape_go.ErrSizeMismatch) {
+		t.Errorf("download larger than DataSize: %v, want ErrSizeMismatch", err)
+	}
+
+	// Fewer bytes than DataSize, on every attempt
+	_, err = mediaVideo(api, len(media)*2, m.URL).Download(context.Background(), io.Discard, nil)
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !strings.Contains(err.Error(), fmt.Sprint(len(media))) {
+		t.Errorf("download smaller than DataSize: %v, want io.ErrUnexpectedEOF after %d bytes", err, len(media))
+	}
+}
+
+func TestDownloadOptions(t *testing.T) {
+	api, _ := newStandin(t)
+	m := newMediaServer(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		serveMedia(w, r)
+	})
+	video := mediaVideo(api, len(media), m.URL)
+
+	// Progress is reported up to the full size
+	var written, total int64
+	_, err := video.Download(context.Background(), io.Discard, nil,
+		ttscrape_go.WithProgress(func(w, t int64) { written, total = w, t }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != int64(len(media)) || total != int64(len(media)) {
+		t.Errorf("last progress %d/%d, want %d/%d", written, total, len(media), len(media))
+	}
+
+	// The session comes from the options rather than always being the first
+	_, err = video.Download(context.Background(), io.Discard, nil, ttscrape_go.WithSession(5))
+	if !errors.Is(err, ttscrape_go.ErrSessionIndex) {
+		t.Errorf("download through a missing session: %v, want ErrSessionIndex", err)
+	}
+
+	// Including for the info request of a video created from an ID
+	_, err = api.Video("1").Download(context.Background(), io.Discard, nil, ttscrape_go.WithSession(5))
+	if !errors.Is(err, ttscrape_go.ErrSessionIndex) {
+		t.Errorf("info through a missing session: %v, want ErrSessionIndex", err)
+	}
+}
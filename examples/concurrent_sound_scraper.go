@@ -20,15 +20,24 @@ type SoundResult struct {
 	Time    time.Duration
 }
 
-// ConcurrentSoundScraper demonstrates how to scrape multiple sounds concurrently
+// ConcurrentSoundScraper demonstrates how to scrape multiple sounds concurrently.
+// An empty msToken falls back to TTSCRAPE_MS_TOKENS or ms_token.
 func ConcurrentSoundScraper(soundIDs []string, msToken string) {
-	if msToken == "" {
-		msToken = os.Getenv("ms_token")
-		if msToken == "" {
-			msToken = "fUpfvLbm0fvAb2-0k1pMGCA4DJDnHNm3ouzvhDE528p9DFZ3i0KxQqs-kAs8ebd2mEpwEH2haKR-6mGiILoOezdG6-H4J4WRA-YwlR05jUvhBRyIF8oTr0I9uHrrJFoa7UHqgQ=="
-			fmt.Println("Using default ms_token")
-		}
+	// Read the client settings from TTSCRAPE_* environment variables
+	cfg, err := ttscrape_go.LoadConfig("")
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	if msToken != "" {
+		cfg.MsTokens = []string{msToken}
 	}
+	if len(cfg.MsTokens) == 0 && cfg.MsTokenFile == "" {
+		fmt.Println("No msToken: pass one or set TTSCRAPE_MS_TOKENS or ms_token")
+		return
+	}
+	cfg.Sessions = 1
+	cfg.BrowserFree = true
 
 	// If no sound IDs are provided, use these default ones
 	if len(soundIDs) == 0 {
@@ -54,19 +63,14 @@ func ConcurrentSoundScraper(soundIDs []string, msToken string) {
 	fmt.Printf("Scraping %d sounds concurrently...\n", len(soundIDs))
 	startTotal := time.Now()
 
-	// Create a new TikTok API client with optimized settings
-	api := ttscrape_go.NewTikTokAPI(0)
-	api.SetHeadless(true)
-	api.SetBrowserFree(true)
-
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 	defer cancel()
 
-	// Create a single session
+	// Create a TikTok API client with a single browser-free session
 	fmt.Println("Creating session...")
 	startSession := time.Now()
-	err := api.CreateSessions(ctx, 1, []string{msToken}, 3, "chromium")
+	api, err := ttscrape_go.NewTikTokAPIFromConfig(ctx, cfg)
 	if err != nil {
 		fmt.Printf("Error creating session: %v\n", err)
 		return
@@ -87,9 +91,7 @@ func ConcurrentSoundScraper(soundIDs []string, msToken string) {
 			
 			// Get sound info
 			sound := api.Sound(id)
-			info, err := sound.Info(map[string]interface{}{
-				"session_index": 0,
-			})
+			info, err := sound.Info(ctx, ttscrape_go.WithSession(0))
 			
 			// Send result to channel
 			resultChan <- SoundResult{
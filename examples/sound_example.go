@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"os"
 	"time"
 
 	ttscrape_go "github.com/fortindustries/ttscrape-go"
@@ -16,42 +15,46 @@ func SoundExample() {
 	// Parse command line flags
 	headless := flag.Bool("headless", true, "Run in headless mode")
 	browserFree := flag.Bool("browser-free", true, "Use browser-free mode after initial setup")
+	configPath := flag.String("config", "", "YAML or JSON config file, overridden by TTSCRAPE_* environment variables")
 	flag.Parse()
 
 	// Start timing the entire process
 	startTotal := time.Now()
 
-	// Get ms_token from environment variable or use the provided one
-	msToken := os.Getenv("ms_token")
-	if msToken == "" {
-		msToken = "fUpfvLbm0fvAb2-0k1pMGCA4DJDnHNm3ouzvhDE528p9DFZ3i0KxQqs-kAs8ebd2mEpwEH2haKR-6mGiILoOezdG6-H4J4WRA-YwlR05jUvhBRyIF8oTr0I9uHrrJFoa7UHqgQ=="
-		fmt.Println("Using default ms_token")
+	// Load the client settings: the flags, then the config file, then the environment
+	cfg := ttscrape_go.DefaultConfig()
+	cfg.Headless = *headless
+	cfg.BrowserFree = *browserFree
+	if *configPath != "" {
+		if err := cfg.LoadFile(*configPath); err != nil {
+			fmt.Printf("Error loading config: %v\n", err)
+			return
+		}
+	}
+	if err := cfg.LoadEnv(); err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	tokens, err := cfg.Tokens()
+	if err != nil || len(tokens) == 0 {
+		fmt.Println("No msToken configured: set TTSCRAPE_MS_TOKENS or ms_token, or ms_tokens in the config file")
+		return
 	}
 
 	// Sound ID to fetch
 	soundID := "7016547803243022337"
 
-	// Create a new TikTok API client
-	fmt.Println("Creating TikTok API client...")
-	startInit := time.Now()
-	api := ttscrape_go.NewTikTokAPI(0)
-	
-	// Configure the API client
-	api.SetHeadless(*headless)
-	api.SetBrowserFree(*browserFree)
-	
-	fmt.Printf("API client created in %v\n", time.Since(startInit))
-	fmt.Printf("Headless mode: %v\n", *headless)
-	fmt.Printf("Browser-free mode: %v\n", *browserFree)
+	fmt.Printf("Headless mode: %v\n", cfg.Headless)
+	fmt.Printf("Browser-free mode: %v\n", cfg.BrowserFree)
 
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	// Create sessions
-	fmt.Println("Creating sessions...")
+	// Create the TikTok API client with its sessions
+	fmt.Println("Creating TikTok API client and sessions...")
 	startSession := time.Now()
-	err := api.CreateSessions(ctx, 1, []string{msToken}, 3, "chromium")
+	api, err := ttscrape_go.NewTikTokAPIFromConfig(ctx, cfg)
 	if err != nil {
 		fmt.Printf("Error creating sessions: %v\n", err)
 		return
@@ -66,9 +69,7 @@ func SoundExample() {
 	// Get sound info
 	fmt.Println("Getting sound info...")
 	startInfo := time.Now()
-	info, err := sound.Info(map[string]interface{}{
-		"session_index": 0,
-	})
+	info, err := sound.Info(ctx, ttscrape_go.WithSession(0))
 	if err != nil {
 		fmt.Printf("Error getting sound info: %v\n", err)
 		return
@@ -82,9 +83,7 @@ func SoundExample() {
 	// Get videos
 	fmt.Println("Getting videos...")
 	startVideos := time.Now()
-	videos, err := sound.Videos(30, 0, map[string]interface{}{
-		"session_index": 0,
-	})
+	videos, err := sound.Videos(ctx, 30, 0, ttscrape_go.WithSession(0))
 	if err != nil {
 		fmt.Printf("Error getting videos: %v\n", err)
 		return
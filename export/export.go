@@ -0,0 +1,236 @@
+// Package export writes sounds and videos as they stream in, one record at a
+// time, so exports of any size never have to be held in memory.
+//
+// Three layouts are supported:
+//
+//   - JSON Lines, one sound or video per line (JSONLWriter)
+//   - CSV with configurable columns picked from the record by dotted path,
+//     e.g. "id", "author.uniqueId", "stats.playCount" (CSVWriter)
+//   - a normalized multi-file layout with sounds, videos and authors tables
+//     linked by ID, written as CSV or JSON Lines (Tables)
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultVideoColumns are the CSV columns used for videos when none are configured
+var DefaultVideoColumns = Columns(
+	"id", "desc", "createTime", "author.id", "author.uniqueId", "music.id",
+	"stats.playCount", "stats.diggCount", "stats.commentCount", "stats.shareCount",
+	"stats.collectCount", "video.duration",
+)
+
+// DefaultSoundColumns are the CSV columns used for sounds when none are
+// configured. Paths are relative to musicInfo.
+var DefaultSoundColumns = Columns(
+	"music.id", "music.title", "music.authorName", "music.duration", "music.original",
+	"author.id", "author.uniqueId", "stats.videoCount",
+)
+
+// Column is a named value picked from a record by dotted path
+type Column struct {
+	Name string // Header in the output
+	Path string // Dotted path into the record, e.g. "stats.playCount" or "contents.0.desc"
+}
+
+// Columns returns columns named after their paths
+func Columns(paths ...string) []Column {
+	columns := make([]Column, len(paths))
+	for i, path := range paths {
+		columns[i] = Column{Name: path, Path: path}
+	}
+	return columns
+}
+
+// ParseColumns parses a comma-separated column list. Each entry is either a
+// path or "name=path".
+func ParseColumns(spec string) []Column {
+	var columns []Column
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if name, path, ok := strings.Cut(entry, "="); ok {
+			columns = append(columns, Column{Name: strings.TrimSpace(name), Path: strings.TrimSpace(path)})
+			continue
+		}
+		columns = append(columns, Column{Name: entry, Path: entry})
+	}
+	return columns
+}
+
+// Lookup returns the value at a dotted path. Numeric segments index into arrays.
+func Lookup(record map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = record
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// Flatten returns every leaf of a record keyed by its dotted path. Arrays are
+// kept as values rather than expanded.
+func Flatten(record map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	flatten(flat, "", record)
+	return flat
+}
+
+// flatten adds the leaves of record to flat under prefix
+func flatten(flat map[string]interface{}, prefix string, record map[string]interface{}) {
+	for key, value := range record {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flatten(flat, key, nested)
+			continue
+		}
+		flat[key] = value
+	}
+}
+
+// FlattenedColumns returns a column for every leaf of record in sorted order
+func FlattenedColumns(record map[string]interface{}) []Column {
+	flat := Flatten(record)
+	paths := make([]string, 0, len(flat))
+	for path := range flat {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return Columns(paths...)
+}
+
+// FormatValue formats a JSON value for a CSV cell. Whole numbers are written
+// without an exponent, so IDs and counts survive the float64 round trip, and
+// objects and arrays are written as JSON.
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+// JSONLWriter writes one JSON document per line
+type JSONLWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLWriter returns a JSON Lines writer. Call Flush when done.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	bw := bufio.NewWriter(w)
+	return &JSONLWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+// Write writes a single record
+func (j *JSONLWriter) Write(record interface{}) error {
+	return j.enc.Encode(record)
+}
+
+// Flush writes any buffered lines to the underlying writer
+func (j *JSONLWriter) Flush() error {
+	return j.w.Flush()
+}
+
+// CSVWriter writes records as CSV rows with a fixed set of columns
+type CSVWriter struct {
+	w       *csv.Writer
+	columns []Column
+	header  bool
+}
+
+// NewCSVWriter returns a CSV writer for the given columns. If columns is empty
+// they are taken from the leaves of the first record. Call Flush when done.
+func NewCSVWriter(w io.Writer, columns []Column) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w), columns: columns}
+}
+
+// Write writes a record as a row, preceded by the header on the first call.
+// Missing paths produce empty cells.
+func (c *CSVWriter) Write(record map[string]interface{}) error {
+	if len(c.columns) == 0 {
+		c.columns = FlattenedColumns(record)
+	}
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	row := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		if value, ok := Lookup(record, column.Path); ok {
+			row[i] = FormatValue(value)
+		}
+	}
+	return c.w.Write(row)
+}
+
+// writeHeader writes the header unless it was already written
+func (c *CSVWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	names := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		names[i] = column.Name
+	}
+	if err := c.w.Write(names); err != nil {
+		return err
+	}
+	c.header = true
+	return nil
+}
+
+// Flush writes any buffered rows to the underlying writer. If no record was
+// written, it writes the header of the configured columns, so an empty export
+// is still a valid CSV file.
+func (c *CSVWriter) Flush() error {
+	if len(c.columns) > 0 {
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,286 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fortindustries/ttscrape-go/export"
+)
+
+// record is a video item struct as TikTok returns it, decoded from JSON
+func record(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var item map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"id": "7300000000000000001",
+		"desc": "hello, \"world\"",
+		"createTime": 1700000000,
+		"author": {"id": "6800000000000000001", "uniqueId": "some.user"},
+		"stats": {"playCount": 1234567, "diggCount": 1.5},
+		"contents": [{"desc": "first"}, {"desc": "second"}],
+		"challenges": [],
+		"private": false,
+		"extra": {}
+	}`), &item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return item
+}
+
+func TestLookup(t *testing.T) {
+	item := record(t)
+	for path, want := range map[string]interface{}{
+		"id":                 "7300000000000000001",
+		"author.uniqueId":    "some.user",
+		"stats.playCount":    float64(1234567),
+		"contents.1.desc":    "second",
+		"private":            false,
+		"extra":              map[string]interface{}{},
+		"contents.0":         map[string]interface{}{"desc": "first"},
+		"challenges":         []interface{}{},
+		"author.id":          "6800000000000000001",
+		"stats.collectCount": nil,
+	} {
+		got, ok := export.Lookup(item, path)
+		if want == nil {
+			if ok {
+				t.Errorf("Lookup(%q) = %v, want missing", path, got)
+			}
+			continue
+		}
+		if !ok || export.FormatValue(got) != export.FormatValue(want) {
+			t.Errorf("Lookup(%q) = %v, %v, want %v", path, got, ok, want)
+		}
+	}
+
+	for _, path := range []string{"contents.2.desc", "contents.-1", "contents.x", "id.more", "author.missing"} {
+		if got, ok := export.Lookup(item, path); ok {
+			t.Errorf("Lookup(%q) = %v, want missing", path, got)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for _, tt := range []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, ""},
+		{"text", "text"},
+		{true, "true"},
+		{float64(7300000000000000000), "7300000000000000000"},
+		{float64(1700000000), "1700000000"},
+		{1.5, "1.5"},
+		{json.Number("12345678901234567890"), "12345678901234567890"},
+		{42, "42"},
+		{int64(-3), "-3"},
+		{[]interface{}{"a", float64(1)}, `["a",1]`},
+		{map[string]interface{}{"k": "v"}, `{"k":"v"}`},
+	} {
+		if got := export.FormatValue(tt.value); got != tt.want {
+			t.Errorf("FormatValue(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	got := export.ParseColumns(" id, plays = stats.playCount ,,author.uniqueId ")
+	want := []export.Column{
+		{Name: "id", Path: "id"},
+		{Name: "plays", Path: "stats.playCount"},
+		{Name: "author.uniqueId", Path: "author.uniqueId"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseColumns = %v, want %v", got, want)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := export.NewCSVWriter(&buf, export.ParseColumns("id,plays=stats.playCount,desc,missing"))
+	if err := w.Write(record(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(map[string]interface{}{"id": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id,plays,desc,missing\n" +
+		"7300000000000000001,1234567,\"hello, \"\"world\"\"\",\n" +
+		"2,,,\n"
+	if buf.String() != want {
+		t.Errorf("got CSV\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestCSVWriterFlattened(t *testing.T) {
+	var buf bytes.Buffer
+	w := export.NewCSVWriter(&buf, nil)
+	if err := w.Write(map[string]interface{}{
+		"b":     "2",
+		"a":     map[string]interface{}{"y": "1", "x": "0"},
+		"empty": map[string]interface{}{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Columns are the sorted leaves of the first record
+	want := "a.x,a.y,b,empty\n0,1,2,{}\n"
+	if buf.String() != want {
+		t.Errorf("got CSV\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestCSVWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.NewCSVWriter(&buf, export.Columns("id", "desc")).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "id,desc\n" {
+		t.Errorf("empty export wrote %q, want the header", buf.String())
+	}
+
+	// Without columns there is nothing to write
+	buf.Reset()
+	if err := export.NewCSVWriter(&buf, nil).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("empty export without columns wrote %q", buf.String())
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := export.NewJSONLWriter(&buf)
+	for _, r := range []interface{}{map[string]interface{}{"id": "1"}, map[string]interface{}{"id": "2"}} {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "{\"id\":\"1\"}\n{\"id\":\"2\"}\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+// readTable reads a table file written by Tables
+func readTable(t *testing.T, dir, name string, format export.Format) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name+"."+string(format)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTables(t *testing.T) {
+	dir := t.TempDir()
+	tables, err := export.NewTables(dir, export.FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sound := map[string]interface{}{"musicInfo": map[string]interface{}{
+		"music":  map[string]interface{}{"id": "1", "title": "Sound"},
+		"author": map[string]interface{}{"id": "6800000000000000001", "uniqueId": "some.user"},
+	}}
+	if err := tables.WriteSound(sound); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same author on two videos is written once, and a video without a
+	// sound takes the one it was listed under
+	item := record(t)
+	if err := tables.WriteVideo("1", item); err != nil {
+		t.Fatal(err)
+	}
+	if err := tables.WriteVideo("1", map[string]interface{}{"id": "2", "author": item["author"], "music": map[string]interface{}{"id": "9"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := item["music"]; ok {
+		t.Error("WriteVideo modified the caller's item")
+	}
+	if err := tables.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	authors := readTable(t, dir, export.AuthorsTable, export.FormatCSV)
+	if want := "id,unique_id,nickname,sec_uid,verified,private_account\n6800000000000000001,some.user,,,,\n"; authors != want {
+		t.Errorf("authors table\n%s\nwant\n%s", authors, want)
+	}
+
+	videos := strings.Split(strings.TrimSpace(readTable(t, dir, export.VideosTable, export.FormatCSV)), "\n")
+	if len(videos) != 3 || !strings.HasPrefix(videos[0], "id,sound_id,author_id,") ||
+		!strings.HasPrefix(videos[1], "7300000000000000001,1,6800000000000000001,") ||
+		!strings.HasPrefix(videos[2], "2,9,6800000000000000001,") {
+		t.Errorf("videos table %q", videos)
+	}
+
+	sounds := readTable(t, dir, export.SoundsTable, export.FormatCSV)
+	if !strings.HasPrefix(sounds, "id,title,author_name,author_id,") || !strings.Contains(sounds, "\n1,Sound,,6800000000000000001,") {
+		t.Errorf("sounds table %q", sounds)
+	}
+}
+
+func TestTablesEmpty(t *testing.T) {
+	dir := t.TempDir()
+	tables, err := export.NewTables(dir, export.FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tables.WriteSound(map[string]interface{}{"music": map[string]interface{}{"id": "1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tables.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tables without rows still have their header
+	if videos := readTable(t, dir, export.VideosTable, export.FormatCSV); !strings.HasPrefix(videos, "id,sound_id,") || strings.Count(videos, "\n") != 1 {
+		t.Errorf("empty videos table %q", videos)
+	}
+	if authors := readTable(t, dir, export.AuthorsTable, export.FormatCSV); authors != "id,unique_id,nickname,sec_uid,verified,private_account\n" {
+		t.Errorf("empty authors table %q", authors)
+	}
+}
+
+func TestTablesJSONL(t *testing.T) {
+	dir := t.TempDir()
+	tables, err := export.NewTables(dir, export.FormatJSONL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tables.WriteVideo("1", record(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tables.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var row map[string]interface{}
+	if err := json.Unmarshal([]byte(readTable(t, dir, export.VideosTable, export.FormatJSONL)), &row); err != nil {
+		t.Fatal(err)
+	}
+	if row["sound_id"] != "1" || row["play_count"] != float64(1234567) || row["share_count"] != nil {
+		t.Errorf("videos row %v", row)
+	}
+
+	if _, err := export.NewTables(t.TempDir(), "xml"); err == nil {
+		t.Error("NewTables with an unknown format succeeded")
+	}
+}
@@ -0,0 +1,215 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Format selects the file format of a Tables export
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+// Table files written by Tables, plus the Format as extension
+const (
+	SoundsTable  = "sounds"
+	VideosTable  = "videos"
+	AuthorsTable = "authors"
+)
+
+// soundColumns are the sounds table columns, relative to musicInfo
+var soundColumns = []Column{
+	{"id", "music.id"},
+	{"title", "music.title"},
+	{"author_name", "music.authorName"},
+	{"author_id", "author.id"},
+	{"duration", "music.duration"},
+	{"original", "music.original"},
+	{"video_count", "stats.videoCount"},
+	{"cover_url", "music.coverLarge"},
+	{"play_url", "music.playUrl"},
+}
+
+// videoColumns are the videos table columns, relative to the item struct.
+// sound_id is filled in from the sound the video was listed under.
+var videoColumns = []Column{
+	{"id", "id"},
+	{"sound_id", "music.id"},
+	{"author_id", "author.id"},
+	{"description", "desc"},
+	{"create_time", "createTime"},
+	{"duration", "video.duration"},
+	{"play_count", "stats.playCount"},
+	{"like_count", "stats.diggCount"},
+	{"comment_count", "stats.commentCount"},
+	{"share_count", "stats.shareCount"},
+	{"collect_count", "stats.collectCount"},
+}
+
+// authorColumns are the authors table columns, relative to the author object
+var authorColumns = []Column{
+	{"id", "id"},
+	{"unique_id", "uniqueId"},
+	{"nickname", "nickname"},
+	{"sec_uid", "secUid"},
+	{"verified", "verified"},
+	{"private_account", "privateAccount"},
+}
+
+// Tables writes sounds, videos and authors to separate files in a directory,
+// linking videos and sounds to authors by ID. Each author is written once.
+// Tables is not safe for concurrent use.
+type Tables struct {
+	format  Format
+	files   []*os.File
+	sounds  tableWriter
+	videos  tableWriter
+	authors tableWriter
+
+	seenAuthors map[string]bool
+}
+
+// tableWriter writes the rows of one table
+type tableWriter interface {
+	writeRow(columns []Column, record map[string]interface{}) error
+	flush() error
+}
+
+// NewTables creates dir if needed and the table files inside it, replacing any
+// existing ones. Call Close when done.
+func NewTables(dir string, format Format) (*Tables, error) {
+	if format != FormatCSV && format != FormatJSONL {
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create export directory: %w", err)
+	}
+
+	t := &Tables{format: format, seenAuthors: make(map[string]bool)}
+	for _, table := range []struct {
+		name    string
+		columns []Column
+		writer  *tableWriter
+	}{
+		{SoundsTable, soundColumns, &t.sounds},
+		{VideosTable, videoColumns, &t.videos},
+		{AuthorsTable, authorColumns, &t.authors},
+	} {
+		file, err := os.Create(filepath.Join(dir, table.name+"."+string(format)))
+		if err != nil {
+			t.Close()
+			return nil, fmt.Errorf("create %s table: %w", table.name, err)
+		}
+		t.files = append(t.files, file)
+
+		if format == FormatCSV {
+			// The columns are known up front, so empty tables still get a header
+			*table.writer = &csvTable{w: NewCSVWriter(file, table.columns)}
+		} else {
+			*table.writer = &jsonlTable{w: NewJSONLWriter(file)}
+		}
+	}
+	return t, nil
+}
+
+// WriteSound writes a sound and its author. info is either the musicInfo
+// object or the full response returned by Sound.Info.
+func (t *Tables) WriteSound(info map[string]interface{}) error {
+	if musicInfo, ok := info["musicInfo"].(map[string]interface{}); ok {
+		info = musicInfo
+	}
+
+	if err := t.writeAuthor(info["author"]); err != nil {
+		return err
+	}
+	return t.sounds.writeRow(soundColumns, info)
+}
+
+// WriteVideo writes a video and its author. soundID is recorded as the
+// video's sound when the item does not carry one.
+func (t *Tables) WriteVideo(soundID string, item map[string]interface{}) error {
+	if err := t.writeAuthor(item["author"]); err != nil {
+		return err
+	}
+
+	if _, ok := Lookup(item, "music.id"); !ok && soundID != "" {
+		// Copy rather than modify the caller's map
+		withSound := make(map[string]interface{}, len(item)+1)
+		for k, v := range item {
+			withSound[k] = v
+		}
+		withSound["music"] = map[string]interface{}{"id": soundID}
+		item = withSound
+	}
+	return t.videos.writeRow(videoColumns, item)
+}
+
+// writeAuthor writes an author the first time its ID is seen
+func (t *Tables) writeAuthor(value interface{}) error {
+	author, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	id := FormatValue(author["id"])
+	if id == "" || t.seenAuthors[id] {
+		return nil
+	}
+	t.seenAuthors[id] = true
+	return t.authors.writeRow(authorColumns, author)
+}
+
+// Close flushes and closes every table file
+func (t *Tables) Close() error {
+	var errs []error
+	for _, w := range []tableWriter{t.sounds, t.videos, t.authors} {
+		if w != nil {
+			errs = append(errs, w.flush())
+		}
+	}
+	for _, file := range t.files {
+		errs = append(errs, file.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// csvTable writes table rows as CSV
+type csvTable struct {
+	w *CSVWriter
+}
+
+// writeRow writes the columns of record, with the header before the first row
+func (c *csvTable) writeRow(columns []Column, record map[string]interface{}) error {
+	c.w.columns = columns
+	return c.w.Write(record)
+}
+
+// flush flushes buffered rows
+func (c *csvTable) flush() error {
+	return c.w.Flush()
+}
+
+// jsonlTable writes table rows as JSON objects keyed by column name, keeping
+// the original value types
+type jsonlTable struct {
+	w *JSONLWriter
+}
+
+// writeRow writes the columns of record
+func (j *jsonlTable) writeRow(columns []Column, record map[string]interface{}) error {
+	row := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		value, _ := Lookup(record, column.Path)
+		row[column.Name] = value
+	}
+	return j.w.Write(row)
+}
+
+// flush flushes buffered rows
+func (j *jsonlTable) flush() error {
+	return j.w.Flush()
+}
@@ -0,0 +1,224 @@
+package ttscrape_go
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// Hashtag represents a TikTok hashtag (called a "challenge" by the API)
+type Hashtag struct {
+	API    *TikTokAPI // Reference to the TikTokAPI
+	Name   string
+	ID     string
+	AsDict map[string]interface{}
+	mu     sync.Mutex
+}
+
+// Info retrieves information about the hashtag
+func (h *Hashtag) Info(ctx context.Context, options ...RequestOption) (map[string]interface{}, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.info(ctx, options...)
+}
+
+// info retrieves information about the hashtag, the caller must hold h.mu
+func (h *Hashtag) info(ctx context.Context, options ...RequestOption) (map[string]interface{}, error) {
+	// Get the API reference
+	api := h.API
+	if api == nil {
+		return nil, errInvalidAPI
+	}
+
+	opts := newRequestOptions(options)
+
+	// Set up URL parameters
+	params := map[string]string{}
+	if h.Name != "" {
+		params["challengeName"] = h.Name
+	}
+	if h.ID != "" {
+		params["challengeId"] = h.ID
+	}
+
+	// Make the request
+	resp, err := api.makeRequest(ctx, opts.call("/api/challenge/detail/", params))
+
+	// Fall back to the tag page if the endpoint is blocked, which needs a name
+	if h.Name != "" {
+		resp, err = withPageFallback(ctx, h.API, opts, resp, err, "challengeInfo",
+			"/tag/"+url.PathEscape(h.Name), scopeChallengeDetail)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if response is valid
+	if resp == nil {
+		return nil, errInvalidResponse
+	}
+
+	// Extract data
+	h.AsDict = resp
+	h.extractFromData()
+
+	return resp, nil
+}
+
+// Videos retrieves videos tagged with this hashtag
+func (h *Hashtag) Videos(ctx context.Context, count int, cursor int, options ...RequestOption) (chan *Video, error) {
+	// Get the API reference
+	api := h.API
+	if api == nil {
+		return nil, errInvalidAPI
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive")
+	}
+
+	// The item list endpoint only accepts the challenge ID, so look it up first if needed
+	h.mu.Lock()
+	if h.ID == "" {
+		resp, err := h.info(ctx, options...)
+		if err == nil {
+			err = CheckStatus(resp) // An unknown hashtag has no ID
+		}
+		if err != nil {
+			h.mu.Unlock()
+			return nil, err
+		}
+	}
+	challengeID := h.ID
+	h.mu.Unlock()
+
+	if challengeID == "" {
+		return nil, fmt.Errorf("could not determine ID for hashtag %s", h.Name)
+	}
+
+	opts := newRequestOptions(options)
+
+	// Create a channel to send videos
+	videos := make(chan *Video, count)
+
+	// Register the goroutine so Shutdown can stop it and wait for it
+	stop, done, err := api.beginPaging()
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a goroutine to fetch videos
+	go func() {
+		defer done()
+		defer close(videos)
+
+		end := pagingEnd{Cursor: cursor, HasMore: true}
+		defer opts.pagingEnded(&end)
+
+		currentCount := 0
+		currentCursor := cursor
+		defer func() {
+			api.observePaging("hashtag", currentCount)
+		}()
+
+		for currentCount < count {
+			// Set up URL parameters
+			params := map[string]string{
+				"challengeID": challengeID,
+				"count":       fmt.Sprintf("%d", 30), // Max count per request
+				"cursor":      fmt.Sprintf("%d", currentCursor),
+			}
+
+			// Make the request
+			resp, err := api.makeRequest(ctx, opts.call("/api/challenge/item_list/", params))
+			if err := pageError(resp, err); err != nil {
+				opts.pagingFailed(err)
+				return
+			}
+
+			// Extract videos
+			itemList, ok := resp["itemList"].([]interface{})
+			api.logger().Debug("page fetched", "entity", "hashtag", "cursor", currentCursor, "items", len(itemList))
+			if !ok || len(itemList) == 0 {
+				end.HasMore = false
+				return
+			}
+
+			// Send videos to channel
+			for i, item := range itemList {
+				if currentCount >= count {
+					return
+				}
+
+				videoMap, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				video := &Video{
+					API:    h.API,
+					AsDict: videoMap,
+				}
+				video.extractFromData()
+
+				select {
+				case videos <- video:
+				case <-stop:
+					opts.pagingFailed(ErrShutdown)
+					return
+				case <-ctx.Done():
+					opts.pagingFailed(ctx.Err())
+					return
+				}
+				currentCount++
+				end.Cursor = currentCursor + i + 1
+			}
+
+			// Update cursor for next page
+			hasMore, ok := resp["hasMore"].(bool)
+			if !ok || !hasMore {
+				end.HasMore = false
+				return
+			}
+
+			nextCursor, ok := cursorFromResponse(resp)
+			if !ok {
+				end.HasMore = false
+				return
+			}
+
+			currentCursor = nextCursor
+			end.Cursor = nextCursor
+		}
+	}()
+
+	return videos, nil
+}
+
+// extractFromData extracts data from the API response
+func (h *Hashtag) extractFromData() {
+	if h.AsDict == nil {
+		return
+	}
+
+	// Extract challenge info
+	challengeInfo, ok := h.AsDict["challengeInfo"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	challenge, ok := challengeInfo["challenge"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	// Extract ID
+	if id, ok := challenge["id"].(string); ok {
+		h.ID = id
+	}
+
+	// Extract name
+	if title, ok := challenge["title"].(string); ok {
+		h.Name = title
+	}
+}
@@ -0,0 +1,220 @@
+package ttscrape_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Item is a video from an item_list response, decoded into typed fields
+// rather than a map. Fields TikTok sends that are not listed here are skipped
+// while decoding; Raw holds the complete item when WithRawItems is used.
+type Item struct {
+	ID         string          `json:"id"`
+	Desc       string          `json:"desc"`
+	CreateTime FlexInt         `json:"createTime"`
+	Author     ItemAuthor      `json:"author"`
+	Music      ItemMusic       `json:"music"`
+	Stats      ItemStats       `json:"stats"`
+	Video      ItemVideo       `json:"video"`
+	Challenges []ItemChallenge `json:"challenges"`
+	IsAd       bool            `json:"isAd"`
+	Raw        json.RawMessage `json:"-"` // The item as sent, only set with WithRawItems
+}
+
+// ItemAuthor is the author of an Item
+type ItemAuthor struct {
+	ID          string `json:"id"`
+	UniqueID    string `json:"uniqueId"`
+	Nickname    string `json:"nickname"`
+	SecUID      string `json:"secUid"`
+	Verified    bool   `json:"verified"`
+	AvatarThumb string `json:"avatarThumb"`
+}
+
+// ItemMusic is the sound used by an Item
+type ItemMusic struct {
+	ID         string  `json:"id"`
+	Title      string  `json:"title"`
+	AuthorName string  `json:"authorName"`
+	Duration   FlexInt `json:"duration"`
+	Original   bool    `json:"original"`
+	PlayURL    string  `json:"playUrl"`
+}
+
+// ItemStats are the engagement counts of an Item
+type ItemStats struct {
+	DiggCount    FlexInt `json:"diggCount"`
+	ShareCount   FlexInt `json:"shareCount"`
+	CommentCount FlexInt `json:"commentCount"`
+	PlayCount    FlexInt `json:"playCount"`
+	CollectCount FlexInt `json:"collectCount"`
+}
+
+// ItemVideo describes the video file of an Item. Renditions are not decoded;
+// use Raw or Video.Renditions for them.
+type ItemVideo struct {
+	ID           string  `json:"id"`
+	Height       FlexInt `json:"height"`
+	Width        FlexInt `json:"width"`
+	Duration     FlexInt `json:"duration"`
+	Ratio        string  `json:"ratio"`
+	Format       string  `json:"format"`
+	Bitrate      FlexInt `json:"bitrate"`
+	Cover        string  `json:"cover"`
+	PlayAddr     string  `json:"playAddr"`
+	DownloadAddr string  `json:"downloadAddr"`
+}
+
+// ItemChallenge is a hashtag on an Item
+type ItemChallenge struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// FlexInt is an integer that TikTok sends either as a JSON number or as a
+// string, such as createTime and the item_list cursor
+type FlexInt int64
+
+// UnmarshalJSON accepts a number, a numeric string or null
+func (n *FlexInt) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) >= 2 && data[0] == '"' {
+		data = data[1 : len(data)-1]
+		if len(data) == 0 {
+			*n = 0
+			return nil
+		}
+	}
+	v, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		// Large counts occasionally arrive as floats
+		f, ferr := strconv.ParseFloat(string(data), 64)
+		if ferr != nil {
+			return fmt.Errorf("decode integer %q: %w", data, err)
+		}
+		v = int64(f)
+	}
+	*n = FlexInt(v)
+	return nil
+}
+
+// ItemListPage is one page of an item_list response
+type ItemListPage struct {
+	StatusCode int
+	StatusMsg  string
+	LogID      string // Identifies the request when reporting problems to TikTok
+	Cursor     FlexInt
+	HasMore    bool
+	Items      []Item
+}
+
+// DecodeItemList decodes an item_list response from r into page without
+// reading the whole body first. Items are decoded one at a time straight into
+// page.Items, whose backing array is reused, so decoding successive pages into
+// the same ItemListPage allocates little beyond the item strings. With keepRaw
+// set each Item's Raw field holds a copy of the item as sent.
+func DecodeItemList(r io.Reader, page *ItemListPage, keepRaw bool) error {
+	items := page.Items[:0]
+	*page = ItemListPage{}
+
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	// Fields other than the ones below are skipped into a reused buffer
+	var skip json.RawMessage
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+
+		switch key {
+		case "itemList":
+			items, err = decodeItems(dec, items, keepRaw)
+		case "statusCode", "status_code":
+			err = dec.Decode(&page.StatusCode)
+		case "statusMsg", "status_msg":
+			err = dec.Decode(&page.StatusMsg)
+		case "cursor":
+			err = dec.Decode(&page.Cursor)
+		case "hasMore":
+			err = dec.Decode(&page.HasMore)
+		case "extra":
+			var extra struct {
+				LogID string `json:"logid"`
+			}
+			err = dec.Decode(&extra)
+			if extra.LogID != "" {
+				page.LogID = extra.LogID
+			}
+		case "log_pb":
+			var logPB struct {
+				ImprID string `json:"impr_id"`
+			}
+			err = dec.Decode(&logPB)
+			if page.LogID == "" {
+				page.LogID = logPB.ImprID
+			}
+		default:
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return fmt.Errorf("decode %s: %w", key, err)
+		}
+	}
+	page.Items = items
+
+	return expectDelim(dec, '}')
+}
+
+// decodeItems decodes the itemList array, appending to items
+func decodeItems(dec *json.Decoder, items []Item, keepRaw bool) ([]Item, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return items, err
+	}
+	if tok == nil {
+		return items, nil // "itemList": null
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return items, fmt.Errorf("itemList is not an array")
+	}
+
+	for dec.More() {
+		// Appending a zero Item clears whatever a previous page left in the slot
+		items = append(items, Item{})
+		item := &items[len(items)-1]
+
+		if !keepRaw {
+			err = dec.Decode(item)
+		} else if err = dec.Decode(&item.Raw); err == nil {
+			err = json.Unmarshal(item.Raw, item)
+		}
+		if err != nil {
+			return items, err
+		}
+	}
+
+	_, err = dec.Token() // ]
+	return items, err
+}
+
+// expectDelim reads the next token and checks that it is delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("expected %q in item_list response, got %v", delim, tok)
+	}
+	return nil
+}
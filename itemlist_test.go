@@ -0,0 +1,176 @@
+package ttscrape_go_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// itemListPage encodes an item_list page holding a synthetic sound's videos
+func itemListPage(tb testing.TB, items int) []byte {
+	tb.Helper()
+	fixture := ttscrapetest.SyntheticFixture("1", items)
+	page, err := json.Marshal(map[string]interface{}{
+		"statusCode": 0,
+		"itemList":   fixture.Videos,
+		"hasMore":    true,
+		"cursor":     "30",
+		"extra":      map[string]interface{}{"logid": "20261019000000"},
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return page
+}
+
+func TestDecodeItemList(t *testing.T) {
+	page := itemListPage(t, 30)
+
+	var list ttscrape_go.ItemListPage
+	if err := ttscrape_go.DecodeItemList(bytes.NewReader(page), &list, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 30 || list.Cursor != 30 || !list.HasMore || list.LogID != "20261019000000" {
+		t.Fatalf("got %d items, cursor %d, hasMore %v, logid %q", len(list.Items), list.Cursor, list.HasMore, list.LogID)
+	}
+	if list.Items[0].ID == "" || list.Items[0].CreateTime == 0 || list.Items[0].Raw != nil {
+		t.Errorf("first item decoded as %+v", list.Items[0])
+	}
+
+	// A shorter page reuses the slice and keeps each item's JSON
+	short := `{"statusCode":0,"itemList":[{"id":"9","createTime":"5"}],"hasMore":false}`
+	if err := ttscrape_go.DecodeItemList(strings.NewReader(short), &list, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.HasMore || list.LogID != "" {
+		t.Fatalf("got %d items, hasMore %v, logid %q after a shorter page", len(list.Items), list.HasMore, list.LogID)
+	}
+	if item := list.Items[0]; item.ID != "9" || item.CreateTime != 5 || item.Author.UniqueID != "" || string(item.Raw) != `{"id":"9","createTime":"5"}` {
+		t.Errorf("reused item decoded as %+v", item)
+	}
+}
+
+func TestDecodeItemListInvalid(t *testing.T) {
+	for _, body := range []string{
+		`[]`,
+		`{"itemList":{}}`,
+		`{"itemList":[{"id":"1"}`,
+		`{"itemList":[{"createTime":"soon"}]}`,
+	} {
+		var list ttscrape_go.ItemListPage
+		if err := ttscrape_go.DecodeItemList(strings.NewReader(body), &list, false); err == nil {
+			t.Errorf("DecodeItemList(%s) succeeded", body)
+		}
+	}
+}
+
+func TestFlexInt(t *testing.T) {
+	for data, want := range map[string]ttscrape_go.FlexInt{
+		`1700000000`:   1700000000,
+		`"1700000000"`: 1700000000,
+		`""`:           0,
+		`null`:         0,
+		`1.5e9`:        1500000000,
+		`"-3"`:         -3,
+	} {
+		var n ttscrape_go.FlexInt
+		if err := json.Unmarshal([]byte(data), &n); err != nil {
+			t.Errorf("unmarshal %s: %v", data, err)
+			continue
+		}
+		if n != want {
+			t.Errorf("unmarshal %s = %d, want %d", data, n, want)
+		}
+	}
+
+	var n ttscrape_go.FlexInt
+	if err := json.Unmarshal([]byte(`"abc"`), &n); err == nil {
+		t.Error(`unmarshal "abc" succeeded`)
+	}
+}
+
+func TestCountMustBePositive(t *testing.T) {
+	api, _ := newStandin(t)
+	ctx := context.Background()
+	sound := api.Sound("1")
+
+	if _, err := sound.Videos(ctx, -1, 0); err == nil {
+		t.Error("Videos with a negative count succeeded")
+	}
+	if _, err := sound.Items(ctx, -1, 0); err == nil {
+		t.Error("Items with a negative count succeeded")
+	}
+	if _, err := sound.NewVideos(ctx, ttscrape_go.NewMemoryCrawlState(), 0); err == nil {
+		t.Error("NewVideos with a zero count succeeded")
+	}
+}
+
+func BenchmarkDecodeItemList(b *testing.B) {
+	page := itemListPage(b, 30)
+	var list ttscrape_go.ItemListPage
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(page)))
+	for range b.N {
+		if err := ttscrape_go.DecodeItemList(bytes.NewReader(page), &list, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeItemListMap decodes the same page the way Sound.Videos does
+func BenchmarkDecodeItemListMap(b *testing.B) {
+	page := itemListPage(b, 30)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(page)))
+	for range b.N {
+		var result map[string]interface{}
+		if err := json.Unmarshal(page, &result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestItemsCancel(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.AddSound("2", ttscrapetest.SyntheticFixture("2", 90))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pagingErr := make(chan error, 1)
+	items, err := api.Sound("2").Items(ctx, 90, 0,
+		ttscrape_go.WithPagingError(func(err error) { pagingErr <- err }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody reads, so the goroutine fills the buffer with the first page and
+	// blocks sending the second; cancelling must still end paging
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.Requests(ttscrapetest.PathMusicItemList) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("second page never requested")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-pagingErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("paging ended with %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("paging goroutine still blocked after ctx was cancelled")
+	}
+	for range items {
+	}
+}
@@ -0,0 +1,85 @@
+package ttscrape_go
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Log levels accepted by NewTikTokAPI. They follow Python's logging module, as
+// in the TikTokApi library this package is modelled on.
+const (
+	LogLevelDebug   = 10
+	LogLevelInfo    = 20
+	LogLevelWarning = 30
+	LogLevelError   = 40
+)
+
+// discardLogger drops every record; it is used when logging is disabled
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// SlogLevel maps a Python-style log level to a slog level. As in Python, a
+// level between two named ones logs from the next named level up, so 25 logs
+// warnings and errors. Zero selects warnings, the TikTokApi default.
+func SlogLevel(logLevel int) slog.Level {
+	switch {
+	case logLevel == 0:
+		return slog.LevelWarn
+	case logLevel <= LogLevelDebug:
+		return slog.LevelDebug
+	case logLevel <= LogLevelInfo:
+		return slog.LevelInfo
+	case logLevel <= LogLevelWarning:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// logLevelNames maps level names to Python-style levels
+var logLevelNames = map[string]int{
+	"debug":   LogLevelDebug,
+	"info":    LogLevelInfo,
+	"warn":    LogLevelWarning,
+	"warning": LogLevelWarning,
+	"error":   LogLevelError,
+}
+
+// ParseLogLevel parses a level name such as "info", or a Python-style number
+// such as "20", into a level for NewTikTokAPI
+func ParseLogLevel(name string) (int, error) {
+	if level, ok := logLevelNames[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return level, nil
+	}
+	if level, err := strconv.Atoi(strings.TrimSpace(name)); err == nil && level >= 0 {
+		return level, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+// newDefaultLogger writes text records at or above logLevel to stderr, keeping
+// stdout free for command output
+func newDefaultLogger(logLevel int) *slog.Logger {
+	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: SlogLevel(logLevel)})
+	return slog.New(handler).With("component", "ttscrape")
+}
+
+// SetLogger sets where structured log records are written, or disables logging
+// if nil. Use slog.New to log through any slog.Handler.
+//
+// Records share these keys: session_index, endpoint, attempt, outcome,
+// http_status, tiktok_status, logid, duration, entity, cursor, items and error.
+func (api *TikTokAPI) SetLogger(logger *slog.Logger) {
+	api.Logger = logger
+}
+
+// logger returns the configured logger or one that discards everything
+func (api *TikTokAPI) logger() *slog.Logger {
+	if api.Logger == nil {
+		return discardLogger
+	}
+	return api.Logger
+}
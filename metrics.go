@@ -0,0 +1,92 @@
+package ttscrape_go
+
+import (
+	"net/url"
+	"time"
+)
+
+// Request outcomes reported to Metrics
+const (
+	OutcomeSuccess         = "success"          // TikTok answered with statusCode 0
+	OutcomeCacheHit        = "cache_hit"        // Served from the response cache
+	OutcomeTikTokError     = "tiktok_error"     // TikTok answered with a non-zero statusCode
+	OutcomeHTTPError       = "http_error"       // TikTok answered with an HTTP status outside 2xx
+	OutcomeInvalidResponse = "invalid_response" // The body was not JSON, e.g. a captcha page
+	OutcomeError           = "error"            // The request failed before a response was read
+)
+
+// RequestMetric describes a single API request attempt
+type RequestMetric struct {
+	Endpoint     string // Endpoint path such as "/api/music/detail/"
+	Outcome      string // One of the Outcome constants
+	HTTPStatus   int    // HTTP status code, 0 if no response was received
+	TikTokStatus int    // TikTok statusCode from the body, 0 on success
+	Duration     time.Duration
+}
+
+// Metrics receives measurements from a TikTokAPI. Implementations must be safe
+// for concurrent use; see the metrics package for a Prometheus implementation.
+type Metrics interface {
+	// ObserveRequest is called for every request attempt, including retries and cache hits
+	ObserveRequest(m RequestMetric)
+	// ObserveRetry is called before a failed request is retried
+	ObserveRetry(endpoint string)
+	// SetActiveSessions is called whenever the number of sessions changes
+	SetActiveSessions(n int)
+	// ObservePaging is called when a paging goroutine finishes, with the number
+	// of videos it sent. entity is "sound", "hashtag", "playlist" or "trending",
+	// or "user_playlists" for User.Playlists, which counts playlists instead.
+	ObservePaging(entity string, videos int)
+}
+
+// nopMetrics discards every measurement
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRequest(RequestMetric) {}
+func (nopMetrics) ObserveRetry(string)          {}
+func (nopMetrics) SetActiveSessions(int)        {}
+func (nopMetrics) ObservePaging(string, int)    {}
+
+// SetMetrics sets where measurements are recorded, or disables them if nil.
+// It may be called while requests are running; measurements already under way
+// may still go to the previous Metrics.
+func (api *TikTokAPI) SetMetrics(metrics Metrics) {
+	api.SwapMetrics(metrics)
+}
+
+// SwapMetrics sets Metrics like SetMetrics and returns the previous ones, so
+// they can be put back later
+func (api *TikTokAPI) SwapMetrics(metrics Metrics) Metrics {
+	api.metricsMu.Lock()
+	previous := api.Metrics
+	api.Metrics = metrics
+	api.metricsMu.Unlock()
+
+	api.metrics().SetActiveSessions(api.SessionCount())
+	return previous
+}
+
+// metrics returns the configured Metrics or a no-op implementation
+func (api *TikTokAPI) metrics() Metrics {
+	api.metricsMu.RLock()
+	defer api.metricsMu.RUnlock()
+
+	if api.Metrics == nil {
+		return nopMetrics{}
+	}
+	return api.Metrics
+}
+
+// observePaging records and logs the number of videos a paging goroutine sent
+func (api *TikTokAPI) observePaging(entity string, videos int) {
+	api.metrics().ObservePaging(entity, videos)
+	api.logger().Debug("paging finished", "entity", entity, "items", videos)
+}
+
+// metricEndpoint returns the path of an endpoint so absolute URLs and paths share labels
+func metricEndpoint(endpoint string) string {
+	if parsedURL, err := url.Parse(endpoint); err == nil && parsedURL.Path != "" {
+		return parsedURL.Path
+	}
+	return endpoint
+}
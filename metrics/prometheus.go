@@ -0,0 +1,227 @@
+// Package metrics records TikTokAPI measurements and exports them in the
+// Prometheus text exposition format, without depending on the Prometheus client.
+//
+//	prom := metrics.NewPrometheus()
+//	api.SetMetrics(prom)
+//	http.Handle("/metrics", prom)
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+)
+
+// DefaultLatencyBuckets are the request latency histogram buckets in seconds
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// DefaultPagingBuckets are the videos-per-paging-run histogram buckets
+var DefaultPagingBuckets = []float64{0, 10, 30, 100, 300, 1000, 3000, 10000}
+
+// Prometheus implements ttscrape_go.Metrics and serves the recorded values in
+// the Prometheus text format. It is safe for concurrent use.
+type Prometheus struct {
+	mu             sync.Mutex
+	requests       map[string]float64 // endpoint, outcome
+	httpStatuses   map[string]float64 // endpoint, code
+	tiktokStatuses map[string]float64 // endpoint, status_code
+	retries        map[string]float64 // endpoint
+	latency        map[string]*histogram
+	paging         map[string]*histogram
+	activeSessions float64
+}
+
+// histogram is a cumulative Prometheus histogram
+type histogram struct {
+	buckets []float64
+	counts  []float64 // counts[i] is the number of observations <= buckets[i]
+	sum     float64
+	count   float64
+}
+
+// Prometheus must satisfy the metrics interface of the API
+var _ ttscrape_go.Metrics = (*Prometheus)(nil)
+
+// NewPrometheus creates an empty set of metrics
+func NewPrometheus() *Prometheus {
+	return &Prometheus{
+		requests:       make(map[string]float64),
+		httpStatuses:   make(map[string]float64),
+		tiktokStatuses: make(map[string]float64),
+		retries:        make(map[string]float64),
+		latency:        make(map[string]*histogram),
+		paging:         make(map[string]*histogram),
+	}
+}
+
+// ObserveRequest records a request attempt
+func (p *Prometheus) ObserveRequest(m ttscrape_go.RequestMetric) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests[labels("endpoint", m.Endpoint, "outcome", m.Outcome)]++
+	if m.HTTPStatus != 0 {
+		p.httpStatuses[labels("endpoint", m.Endpoint, "code", strconv.Itoa(m.HTTPStatus))]++
+	}
+	if m.TikTokStatus != 0 {
+		p.tiktokStatuses[labels("endpoint", m.Endpoint, "status_code", strconv.Itoa(m.TikTokStatus))]++
+	}
+	if m.Outcome != ttscrape_go.OutcomeCacheHit {
+		observe(p.latency, labels("endpoint", m.Endpoint), DefaultLatencyBuckets, m.Duration.Seconds())
+	}
+}
+
+// ObserveRetry records a retried request
+func (p *Prometheus) ObserveRetry(endpoint string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.retries[labels("endpoint", endpoint)]++
+}
+
+// SetActiveSessions records the number of sessions
+func (p *Prometheus) SetActiveSessions(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.activeSessions = float64(n)
+}
+
+// ObservePaging records the number of videos sent by a paging run
+func (p *Prometheus) ObservePaging(entity string, videos int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	observe(p.paging, labels("entity", entity), DefaultPagingBuckets, float64(videos))
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (p *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	writeCounter(cw, "ttscrape_requests_total", "API request attempts by endpoint and outcome.", p.requests)
+	writeHistograms(cw, "ttscrape_request_duration_seconds", "API request latency by endpoint, excluding cache hits.", p.latency)
+	writeCounter(cw, "ttscrape_http_responses_total", "HTTP responses by endpoint and status code.", p.httpStatuses)
+	writeCounter(cw, "ttscrape_tiktok_errors_total", "Responses with a non-zero TikTok statusCode by endpoint and status.", p.tiktokStatuses)
+	writeCounter(cw, "ttscrape_retries_total", "Retried API requests by endpoint.", p.retries)
+
+	fmt.Fprintf(cw, "# HELP ttscrape_active_sessions Number of sessions in the pool.\n")
+	fmt.Fprintf(cw, "# TYPE ttscrape_active_sessions gauge\n")
+	fmt.Fprintf(cw, "ttscrape_active_sessions %s\n", formatFloat(p.activeSessions))
+
+	writeHistograms(cw, "ttscrape_videos_paged", "Videos sent per paging run by entity.", p.paging)
+
+	if err := cw.w.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, cw.err
+}
+
+// observe adds a value to the histogram with the given labels
+func observe(histograms map[string]*histogram, key string, buckets []float64, value float64) {
+	h, ok := histograms[key]
+	if !ok {
+		h = &histogram{buckets: buckets, counts: make([]float64, len(buckets))}
+		histograms[key] = h
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// writeCounter writes a counter family
+func writeCounter(w io.Writer, name, help string, values map[string]float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", name)
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s{%s} %s\n", name, key, formatFloat(values[key]))
+	}
+}
+
+// writeHistograms writes a histogram family
+func writeHistograms(w io.Writer, name, help string, histograms map[string]*histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	for _, key := range sortedKeys(histograms) {
+		h := histograms[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %s\n", name, key, formatFloat(bound), formatFloat(h.counts[i]))
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %s\n", name, key, formatFloat(h.count))
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, key, formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count{%s} %s\n", name, key, formatFloat(h.count))
+	}
+}
+
+// labels formats label name/value pairs as they appear between braces
+func labels(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// escapeLabel escapes a label value for the text format
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatFloat formats a sample value
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of m in order so output is stable
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// countingWriter counts bytes written and remembers the first error
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+// Write writes to the underlying writer unless an earlier write failed
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
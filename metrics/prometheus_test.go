@@ -0,0 +1,127 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/metrics"
+)
+
+// golden is the exposition of the observations made by record. Label values
+// are escaped, keys are sorted and cache hits stay out of the latency histogram.
+const golden = `# HELP ttscrape_requests_total API request attempts by endpoint and outcome.
+# TYPE ttscrape_requests_total counter
+ttscrape_requests_total{endpoint="/api/music/detail/",outcome="cache_hit"} 1
+ttscrape_requests_total{endpoint="/api/music/detail/",outcome="success"} 2
+ttscrape_requests_total{endpoint="page:a\"b\\c\nd",outcome="tiktok_error"} 1
+# HELP ttscrape_request_duration_seconds API request latency by endpoint, excluding cache hits.
+# TYPE ttscrape_request_duration_seconds histogram
+ttscrape_request_duration_seconds_bucket{endpoint="/api/music/detail/",le="0.05"} 0
+ttscrape_request_duration_seconds_bucket{endpoint="/api/music/detail/",le="0.1"} 1
+ttscrape_request_duration_seconds_bucket{endpoint="/api/music/detail/",le="0.25"} 1
+ttscrape_request_duration_seconds_bucket{endpoint="/api/music/detail/",le="0.5"} 1
+ttscrape_request_duration_seconds_bucket{endpoint="/api/music/detail/",le="1"} 1
+ttscrape_request_duration_seconds_bucket{endpoint="/api/music/detail/",le="2.5"} 2
+ttscrape_request_duration_seconds_bucket{endpoint="/api/music/detail/",le="5"} 2
+ttscrape_request_duration_seconds_bucket{endpoint="/api/music/detail/",le="10"} 2
+ttscrape_request_duration_seconds_bucket{endpoint="/api/music/detail/",le="30"} 2
+ttscrape_request_duration_seconds_bucket{endpoint="/api/music/detail/",le="+Inf"} 2
+ttscrape_request_duration_seconds_sum{endpoint="/api/music/detail/"} 2.08
+ttscrape_request_duration_seconds_count{endpoint="/api/music/detail/"} 2
+ttscrape_request_duration_seconds_bucket{endpoint="page:a\"b\\c\nd",le="0.05"} 0
+ttscrape_request_duration_seconds_bucket{endpoint="page:a\"b\\c\nd",le="0.1"} 0
+ttscrape_request_duration_seconds_bucket{endpoint="page:a\"b\\c\nd",le="0.25"} 0
+ttscrape_request_duration_seconds_bucket{endpoint="page:a\"b\\c\nd",le="0.5"} 0
+ttscrape_request_duration_seconds_bucket{endpoint="page:a\"b\\c\nd",le="1"} 0
+ttscrape_request_duration_seconds_bucket{endpoint="page:a\"b\\c\nd",le="2.5"} 0
+ttscrape_request_duration_seconds_bucket{endpoint="page:a\"b\\c\nd",le="5"} 0
+ttscrape_request_duration_seconds_bucket{endpoint="page:a\"b\\c\nd",le="10"} 0
+ttscrape_request_duration_seconds_bucket{endpoint="page:a\"b\\c\nd",le="30"} 0
+ttscrape_request_duration_seconds_bucket{endpoint="page:a\"b\\c\nd",le="+Inf"} 1
+ttscrape_request_duration_seconds_sum{endpoint="page:a\"b\\c\nd"} 40
+ttscrape_request_duration_seconds_count{endpoint="page:a\"b\\c\nd"} 1
+# HELP ttscrape_http_responses_total HTTP responses by endpoint and status code.
+# TYPE ttscrape_http_responses_total counter
+ttscrape_http_responses_total{endpoint="/api/music/detail/",code="200"} 2
+ttscrape_http_responses_total{endpoint="page:a\"b\\c\nd",code="200"} 1
+# HELP ttscrape_tiktok_errors_total Responses with a non-zero TikTok statusCode by endpoint and status.
+# TYPE ttscrape_tiktok_errors_total counter
+ttscrape_tiktok_errors_total{endpoint="page:a\"b\\c\nd",status_code="10218"} 1
+# HELP ttscrape_retries_total Retried API requests by endpoint.
+# TYPE ttscrape_retries_total counter
+ttscrape_retries_total{endpoint="/api/music/detail/"} 1
+# HELP ttscrape_active_sessions Number of sessions in the pool.
+# TYPE ttscrape_active_sessions gauge
+ttscrape_active_sessions 2
+# HELP ttscrape_videos_paged Videos sent per paging run by entity.
+# TYPE ttscrape_videos_paged histogram
+ttscrape_videos_paged_bucket{entity="sound",le="0"} 1
+ttscrape_videos_paged_bucket{entity="sound",le="10"} 1
+ttscrape_videos_paged_bucket{entity="sound",le="30"} 1
+ttscrape_videos_paged_bucket{entity="sound",le="100"} 2
+ttscrape_videos_paged_bucket{entity="sound",le="300"} 2
+ttscrape_videos_paged_bucket{entity="sound",le="1000"} 2
+ttscrape_videos_paged_bucket{entity="sound",le="3000"} 2
+ttscrape_videos_paged_bucket{entity="sound",le="10000"} 2
+ttscrape_videos_paged_bucket{entity="sound",le="+Inf"} 2
+ttscrape_videos_paged_sum{entity="sound"} 45
+ttscrape_videos_paged_count{entity="sound"} 2
+`
+
+// record feeds prom a fixed set of observations
+func record(prom *metrics.Prometheus) {
+	prom.ObserveRequest(ttscrape_go.RequestMetric{
+		Endpoint: "/api/music/detail/", Outcome: ttscrape_go.OutcomeSuccess,
+		HTTPStatus: 200, Duration: 80 * time.Millisecond,
+	})
+	prom.ObserveRequest(ttscrape_go.RequestMetric{
+		Endpoint: "/api/music/detail/", Outcome: ttscrape_go.OutcomeSuccess,
+		HTTPStatus: 200, Duration: 2 * time.Second,
+	})
+	prom.ObserveRequest(ttscrape_go.RequestMetric{
+		Endpoint: "/api/music/detail/", Outcome: ttscrape_go.OutcomeCacheHit,
+	})
+	prom.ObserveRequest(ttscrape_go.RequestMetric{
+		Endpoint: "page:a\"b\\c\nd", Outcome: ttscrape_go.OutcomeTikTokError,
+		HTTPStatus: 200, TikTokStatus: 10218, Duration: 40 * time.Second,
+	})
+	prom.ObserveRetry("/api/music/detail/")
+	prom.SetActiveSessions(2)
+	prom.ObservePaging("sound", 0)
+	prom.ObservePaging("sound", 45)
+}
+
+func TestPrometheusGolden(t *testing.T) {
+	prom := metrics.NewPrometheus()
+	record(prom)
+
+	var b strings.Builder
+	n, err := prom.WriteTo(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(b.Len()) {
+		t.Errorf("WriteTo returned %d, wrote %d bytes", n, b.Len())
+	}
+	if b.String() != golden {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), golden)
+	}
+}
+
+func TestPrometheusServeHTTP(t *testing.T) {
+	prom := metrics.NewPrometheus()
+	record(prom)
+
+	rec := httptest.NewRecorder()
+	prom.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type %q", ct)
+	}
+	if rec.Body.String() != golden {
+		t.Errorf("served body differs from WriteTo")
+	}
+}
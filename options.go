@@ -0,0 +1,114 @@
+package ttscrape_go
+
+import (
+	"time"
+)
+
+// RequestOption configures a single entity call such as Sound.Info or
+// Sound.Videos. Later options override earlier ones.
+type RequestOption func(*requestOptions)
+
+// CachePolicy controls how a call uses the API's response cache
+type CachePolicy int
+
+const (
+	// CacheDefault serves cached responses and caches fresh ones
+	CacheDefault CachePolicy = iota
+	// CacheRefresh skips the cache lookup but caches the fresh response
+	CacheRefresh
+	// CacheBypass neither reads nor writes the cache
+	CacheBypass
+)
+
+// WithSession sends the call's requests through the session at index
+func WithSession(index int) RequestOption {
+	return func(o *requestOptions) {
+		o.SessionIndex = index
+	}
+}
+
+// WithMsToken overrides the session's msToken for the call
+func WithMsToken(msToken string) RequestOption {
+	return func(o *requestOptions) {
+		o.MsToken = msToken
+	}
+}
+
+// WithHeaders adds HTTP headers to the call's requests, replacing session
+// headers of the same name
+func WithHeaders(headers map[string]string) RequestOption {
+	return func(o *requestOptions) {
+		for k, v := range headers {
+			o.Headers[k] = v
+		}
+	}
+}
+
+// WithParams adds URL parameters to the call's requests, replacing session
+// parameters of the same name. Parameters the entity sets itself, such as
+// cursor or count, are never replaced.
+func WithParams(params map[string]string) RequestOption {
+	return func(o *requestOptions) {
+		for k, v := range params {
+			o.Params[k] = v
+		}
+	}
+}
+
+// WithTimeout bounds each request of the call, including its retries. Paging
+// calls apply it to every page separately.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.Timeout = timeout
+	}
+}
+
+// WithCachePolicy sets how the call uses the response cache
+func WithCachePolicy(policy CachePolicy) RequestOption {
+	return func(o *requestOptions) {
+		o.CachePolicy = policy
+	}
+}
+
+// WithPageFallback overrides TikTokAPI.PageFallback for the call
+func WithPageFallback(enabled bool) RequestOption {
+	return func(o *requestOptions) {
+		o.PageFallback = &enabled
+	}
+}
+
+// WithProgress reports the progress of Video.Download and Sound.DownloadAudio
+func WithProgress(progress ProgressFunc) RequestOption {
+	return func(o *requestOptions) {
+		o.Progress = progress
+	}
+}
+
+// WithRawItems keeps each item's JSON in Item.Raw for Sound.Items
+func WithRawItems() RequestOption {
+	return func(o *requestOptions) {
+		o.RawItems = true
+	}
+}
+
+// WithPagingError calls fn with the error that ends a paging method such as
+// Sound.Videos early: a failed request, a TikTok error status (*StatusError)
+// or ErrShutdown. fn is called at most once, before the channel is closed, so
+// it has been called by the time ranging over the channel finishes.
+func WithPagingError(fn func(err error)) RequestOption {
+	return func(o *requestOptions) {
+		o.PagingError = fn
+	}
+}
+
+// WithNextCursor calls fn when a cursor-paged method such as Sound.Videos
+// ends, with the cursor that continues the listing after the last item sent
+// and whether TikTok has more. TikTok's list cursors are offsets, so a listing
+// cut short by its count in the middle of a page resumes at the first item it
+// did not send; one that failed resumes at the first item it could not fetch.
+// Like WithPagingError's handler, fn is called before the channel is closed.
+func WithNextCursor(fn func(cursor int, hasMore bool)) RequestOption {
+	return func(o *requestOptions) {
+		o.NextCursor = fn
+	}
+}
@@ -0,0 +1,157 @@
+package ttscrape_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// rehydrationScriptID is the id of the script tag holding a page's server-rendered data
+const rehydrationScriptID = "__UNIVERSAL_DATA_FOR_REHYDRATION__"
+
+// ErrNoPageData is returned when a page does not embed the expected rehydration data
+var ErrNoPageData = errors.New("page has no rehydration data")
+
+// Rehydration scopes holding each entity's data, in the same shape as the matching API response
+const (
+	scopeMusicDetail     = "webapp.music-detail"
+	scopeVideoDetail     = "webapp.video-detail"
+	scopeUserDetail      = "webapp.user-detail"
+	scopeChallengeDetail = "webapp.challenge-detail"
+)
+
+// SetPageFallback sets whether entity Info calls fall back to the server-rendered
+// page when the API endpoint fails. It can be overridden per call with
+// WithPageFallback.
+func (api *TikTokAPI) SetPageFallback(pageFallback bool) {
+	api.PageFallback = pageFallback
+}
+
+// PageFallbackEnabled reports whether page fallback is enabled by default
+func (api *TikTokAPI) PageFallbackEnabled() bool {
+	return api.PageFallback
+}
+
+// FetchPageData fetches a tiktok.com page and returns the given scope of its
+// embedded __UNIVERSAL_DATA_FOR_REHYDRATION__ JSON (e.g. "webapp.music-detail").
+// urlStr may be a path such as "/music/sound-123", resolved like MakeRequest's.
+// The page is fetched like an API request: through the session's rate limiter,
+// with retries, metrics and the response cache, within RequestTimeout.
+func (api *TikTokAPI) FetchPageData(ctx context.Context, urlStr string, scope string, sessionIndex int) (map[string]interface{}, error) {
+	return api.fetchPage(ctx, apiCall{
+		Endpoint:     urlStr,
+		SessionIndex: sessionIndex,
+	}, scope)
+}
+
+// fetchPage fetches the page at call.Endpoint and returns the scope of its rehydration data
+func (api *TikTokAPI) fetchPage(ctx context.Context, call apiCall, scope string) (map[string]interface{}, error) {
+	call.PageScope = scope
+	headers := make(map[string]string, len(call.Headers)+1)
+	for k, v := range call.Headers {
+		headers[k] = v
+	}
+	headers["Accept"] = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	call.Headers = headers
+
+	var scoped map[string]interface{}
+	err := api.request(ctx, call, func(r io.Reader) (responseStatus, error) {
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return responseStatus{}, err
+		}
+		scoped, err = pageScope(string(body), scope)
+		if err != nil {
+			return responseStatus{}, err
+		}
+		return mapResponseStatus(scoped), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetch page %s: %w", call.Endpoint, err)
+	}
+
+	// Pages for missing or private entities still render, with a non-zero status code
+	if err := CheckStatus(scoped); err != nil {
+		return nil, fmt.Errorf("fetch page %s: %w", call.Endpoint, err)
+	}
+
+	return scoped, nil
+}
+
+// pageScope returns the given scope of a page's rehydration data
+func pageScope(html string, scope string) (map[string]interface{}, error) {
+	data, err := extractRehydrationData(html)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultScope, ok := data["__DEFAULT_SCOPE__"].(map[string]interface{})
+	if !ok {
+		return nil, ErrNoPageData
+	}
+	scoped, ok := defaultScope[scope].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: missing scope %s", ErrNoPageData, scope)
+	}
+	return scoped, nil
+}
+
+// extractRehydrationData decodes the rehydration script embedded in a page
+func extractRehydrationData(html string) (map[string]interface{}, error) {
+	// Find the script tag
+	idx := strings.Index(html, `id="`+rehydrationScriptID+`"`)
+	if idx == -1 {
+		return nil, ErrNoPageData
+	}
+
+	// Skip to the end of the opening tag
+	start := strings.Index(html[idx:], ">")
+	if start == -1 {
+		return nil, ErrNoPageData
+	}
+	start += idx + 1
+
+	end := strings.Index(html[start:], "</script>")
+	if end == -1 {
+		return nil, ErrNoPageData
+	}
+
+	// Parse JSON
+	var data map[string]interface{}
+	err := json.Unmarshal([]byte(html[start:start+end]), &data)
+	if err != nil {
+		return nil, fmt.Errorf("decode rehydration data: %w", err)
+	}
+
+	return data, nil
+}
+
+// withPageFallback returns resp when the API call succeeded and contains key.
+// Otherwise, if page fallback is enabled, it returns the page's data instead.
+func withPageFallback(ctx context.Context, api *TikTokAPI, opts requestOptions, resp map[string]interface{}, err error, key string, pageURL string, scope string) (map[string]interface{}, error) {
+	if err == nil && resp != nil && resp[key] != nil {
+		return resp, nil
+	}
+
+	// Check whether fallback is enabled for this call
+	enabled := api.PageFallbackEnabled()
+	if opts.PageFallback != nil {
+		enabled = *opts.PageFallback
+	}
+	if !enabled {
+		return resp, err
+	}
+
+	pageData, pageErr := api.fetchPage(ctx, opts.call(pageURL, map[string]string{}), scope)
+	if pageErr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("%w (page fallback failed: %v)", err, pageErr)
+		}
+		return nil, fmt.Errorf("page fallback failed: %w", pageErr)
+	}
+
+	return pageData, nil
+}
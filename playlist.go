@@ -0,0 +1,192 @@
+package ttscrape_go
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Playlist represents a TikTok playlist (called a "mix" by the API)
+type Playlist struct {
+	API        *TikTokAPI // Reference to the TikTokAPI
+	ID         string
+	Name       string
+	VideoCount int
+	AsDict     map[string]interface{}
+	mu         sync.Mutex
+}
+
+// Info retrieves information about the playlist
+func (p *Playlist) Info(ctx context.Context, options ...RequestOption) (map[string]interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Get the API reference
+	api := p.API
+	if api == nil {
+		return nil, errInvalidAPI
+	}
+
+	opts := newRequestOptions(options)
+
+	// Set up URL parameters
+	params := map[string]string{
+		"mixId": p.ID,
+	}
+
+	// Make the request
+	resp, err := api.makeRequest(ctx, opts.call("/api/mix/detail/", params))
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if response is valid
+	if resp == nil {
+		return nil, errInvalidResponse
+	}
+
+	// Extract data
+	p.AsDict = resp
+	p.extractFromData()
+
+	return resp, nil
+}
+
+// Videos retrieves the videos in the playlist
+func (p *Playlist) Videos(ctx context.Context, count int, cursor int, options ...RequestOption) (chan *Video, error) {
+	// Get the API reference
+	api := p.API
+	if api == nil {
+		return nil, errInvalidAPI
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive")
+	}
+
+	opts := newRequestOptions(options)
+
+	// Create a channel to send videos
+	videos := make(chan *Video, count)
+
+	// Register the goroutine so Shutdown can stop it and wait for it
+	stop, done, err := api.beginPaging()
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a goroutine to fetch videos
+	go func() {
+		defer done()
+		defer close(videos)
+
+		end := pagingEnd{Cursor: cursor, HasMore: true}
+		defer opts.pagingEnded(&end)
+
+		currentCount := 0
+		currentCursor := cursor
+		defer func() {
+			api.observePaging("playlist", currentCount)
+		}()
+
+		for currentCount < count {
+			// Set up URL parameters
+			params := map[string]string{
+				"mixId":  p.ID,
+				"count":  fmt.Sprintf("%d", 30), // Max count per request
+				"cursor": fmt.Sprintf("%d", currentCursor),
+			}
+
+			// Make the request
+			resp, err := api.makeRequest(ctx, opts.call("/api/mix/item_list/", params))
+			if err := pageError(resp, err); err != nil {
+				opts.pagingFailed(err)
+				return
+			}
+
+			// Extract videos
+			itemList, ok := resp["itemList"].([]interface{})
+			api.logger().Debug("page fetched", "entity", "playlist", "cursor", currentCursor, "items", len(itemList))
+			if !ok || len(itemList) == 0 {
+				end.HasMore = false
+				return
+			}
+
+			// Send videos to channel
+			for i, item := range itemList {
+				if currentCount >= count {
+					return
+				}
+
+				videoMap, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				video := &Video{
+					API:    p.API,
+					AsDict: videoMap,
+				}
+				video.extractFromData()
+
+				select {
+				case videos <- video:
+				case <-stop:
+					opts.pagingFailed(ErrShutdown)
+					return
+				case <-ctx.Done():
+					opts.pagingFailed(ctx.Err())
+					return
+				}
+				currentCount++
+				end.Cursor = currentCursor + i + 1
+			}
+
+			// Update cursor for next page
+			hasMore, ok := resp["hasMore"].(bool)
+			if !ok || !hasMore {
+				end.HasMore = false
+				return
+			}
+
+			nextCursor, ok := cursorFromResponse(resp)
+			if !ok {
+				end.HasMore = false
+				return
+			}
+
+			currentCursor = nextCursor
+			end.Cursor = nextCursor
+		}
+	}()
+
+	return videos, nil
+}
+
+// extractFromData extracts data from the API response
+func (p *Playlist) extractFromData() {
+	if p.AsDict == nil {
+		return
+	}
+
+	// Info responses hold the mix under mixInfo, playlists listed by
+	// User.Playlists are the mix itself
+	mixInfo, ok := p.AsDict["mixInfo"].(map[string]interface{})
+	if !ok {
+		mixInfo = p.AsDict
+	}
+
+	// Extract ID
+	if id, ok := mixInfo["id"].(string); ok {
+		p.ID = id
+	}
+
+	// Extract name
+	if name, ok := mixInfo["name"].(string); ok {
+		p.Name = name
+	}
+
+	// Extract video count
+	if videoCount, ok := mixInfo["videoCount"].(float64); ok {
+		p.VideoCount = int(videoCount)
+	}
+}
@@ -0,0 +1,111 @@
+package ttscrape_go_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/metrics"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+func TestPlaylistInfo(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.AddPlaylist("7200000000000000001", ttscrapetest.SyntheticPlaylist("7200000000000000001", 12))
+
+	// Info returns the whole response and keeps it in AsDict, like other entities
+	playlist := api.Playlist("7200000000000000001")
+	resp, err := playlist.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ttscrape_go.CheckStatus(resp); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp["mixInfo"]; !ok {
+		t.Errorf("Info returned %v, want the response with mixInfo", resp)
+	}
+	if _, ok := playlist.AsDict["mixInfo"]; !ok {
+		t.Errorf("AsDict is %v, want the response", playlist.AsDict)
+	}
+	if playlist.Name != "Playlist 7200000000000000001" || playlist.VideoCount != 12 {
+		t.Errorf("got name %q, %d videos", playlist.Name, playlist.VideoCount)
+	}
+
+	// An unknown playlist is a TikTok status, not a malformed response
+	resp, err = api.Playlist("9").Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statusErr *ttscrape_go.StatusError
+	if err := ttscrape_go.CheckStatus(resp); !errors.As(err, &statusErr) || statusErr.Code != ttscrapetest.StatusNotFound {
+		t.Errorf("unknown playlist status: %v", err)
+	}
+}
+
+func TestPlaylistVideos(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.SetPageSize(10)
+	fixture := ttscrapetest.SyntheticPlaylist("7200000000000000001", 45)
+	srv.AddPlaylist("7200000000000000001", fixture)
+
+	videos, err := api.Playlist("7200000000000000001").Videos(context.Background(), 25, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := 10
+	for video := range videos {
+		if want := fixture.Videos[i]["id"]; video.ID != want {
+			t.Errorf("video %d is %s, want %s", i, video.ID, want)
+		}
+		i++
+	}
+	if i != 35 {
+		t.Errorf("got %d videos, want 25", i-10)
+	}
+	if n := srv.Requests(ttscrapetest.PathMixItemList); n != 3 {
+		t.Errorf("got %d item_list requests, want 3", n)
+	}
+}
+
+func TestUserPlaylists(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.SetPageSize(10)
+	srv.AddUser("someone", ttscrapetest.SyntheticUser("someone", 25))
+	prom := metrics.NewPrometheus()
+	api.SetMetrics(prom)
+
+	// The secUid is looked up first, then every page is listed
+	playlists, err := api.User("someone").Playlists(context.Background(), 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for playlist := range playlists {
+		if playlist.ID == "" || playlist.VideoCount == 0 {
+			t.Errorf("playlist decoded as %+v", playlist)
+		}
+		names = append(names, playlist.Name)
+	}
+	if len(names) != 25 || names[24] != "Playlist 24 by someone" {
+		t.Errorf("got %d playlists, last %q", len(names), names[len(names)-1])
+	}
+	if n := srv.Requests(ttscrapetest.PathUserDetail); n != 1 {
+		t.Errorf("got %d user/detail requests, want 1", n)
+	}
+
+	// The run is recorded like other paging runs
+	var b strings.Builder
+	prom.WriteTo(&b)
+	if !strings.Contains(b.String(), `ttscrape_videos_paged_sum{entity="user_playlists"} 25`) {
+		t.Errorf("paging run not recorded:\n%s", b.String())
+	}
+
+	// An unknown user fails before paging starts
+	var statusErr *ttscrape_go.StatusError
+	if _, err := api.User("nobody").Playlists(context.Background(), 10, 0); !errors.As(err, &statusErr) {
+		t.Errorf("Playlists of an unknown user: %v, want a StatusError", err)
+	}
+}
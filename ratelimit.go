@@ -0,0 +1,53 @@
+package ttscrape_go
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// SetRateLimit limits every session to perSecond API requests per second,
+// allowing bursts of up to burst requests. A perSecond of 0 removes the limit.
+// Cache hits are not limited. It applies to existing and future sessions.
+func (api *TikTokAPI) SetRateLimit(perSecond float64, burst int) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	api.RateLimit = perSecond
+	api.RateBurst = burst
+	for _, session := range api.sessions {
+		if session != nil && session.limiter != nil {
+			session.limiter.SetLimit(api.limit())
+			session.limiter.SetBurst(api.burst())
+		}
+	}
+}
+
+// newLimiter returns a limiter for a new session
+func (api *TikTokAPI) newLimiter() *rate.Limiter {
+	return rate.NewLimiter(api.limit(), api.burst())
+}
+
+// limit converts RateLimit to a rate.Limit, treating 0 as unlimited
+func (api *TikTokAPI) limit() rate.Limit {
+	if api.RateLimit <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(api.RateLimit)
+}
+
+// burst returns RateBurst, at least 1 so limited sessions can make requests
+func (api *TikTokAPI) burst() int {
+	if api.RateBurst < 1 {
+		return 1
+	}
+	return api.RateBurst
+}
+
+// wait blocks until the session may make another API request
+func (s *TikTokSession) wait(ctx context.Context) error {
+	if s.limiter == nil {
+		return nil
+	}
+	return s.limiter.Wait(ctx)
+}
@@ -0,0 +1,130 @@
+package ttscrape_go
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// errInvalidAPI is returned when an entity was built without a usable API reference
+var errInvalidAPI = errors.New("invalid API reference")
+
+// apiCall describes a single API request made on behalf of an entity
+type apiCall struct {
+	Endpoint     string            // Endpoint path such as "/api/music/detail/", or an absolute URL
+	Params       map[string]string // Request specific URL parameters, merged over the session's
+	Headers      map[string]string
+	SessionIndex int
+	Timeout      time.Duration // Bounds the request and its retries, 0 for no limit
+	CachePolicy  CachePolicy
+	PageScope    string // Set for server-rendered pages and short links, which are sent without the session's API parameters
+	// CheckRedirect replaces the client's redirect policy when set. A redirect
+	// response it stops at with http.ErrUseLastResponse counts as a success.
+	CheckRedirect func(req *http.Request, via []*http.Request) error
+}
+
+// metricEndpoint returns the endpoint label of the call's metrics and spans.
+// Pages are labelled by scope rather than by their many paths.
+func (c apiCall) metricEndpoint() string {
+	if c.PageScope != "" {
+		return "page:" + c.PageScope
+	}
+	return metricEndpoint(c.Endpoint)
+}
+
+// requestOptions holds the options shared by every entity method
+type requestOptions struct {
+	SessionIndex int
+	MsToken      string
+	Headers      map[string]string
+	Params       map[string]string
+	Timeout      time.Duration
+	CachePolicy  CachePolicy
+	PageFallback *bool                          // Overrides TikTokAPI.PageFallback when set
+	Progress     ProgressFunc                   // Only used by downloads
+	RawItems     bool                           // Only used by Sound.Items
+	PagingError  func(error)                    // Only used by paging methods
+	NextCursor   func(cursor int, hasMore bool) // Only used by paging methods
+}
+
+// newRequestOptions applies options in order. Nil options are skipped.
+func newRequestOptions(options []RequestOption) requestOptions {
+	opts := requestOptions{
+		Headers: map[string]string{},
+		Params:  map[string]string{},
+	}
+	for _, option := range options {
+		if option != nil {
+			option(&opts)
+		}
+	}
+	return opts
+}
+
+// pagingFailed reports the error that ended paging to the WithPagingError handler
+func (o requestOptions) pagingFailed(err error) {
+	if o.PagingError != nil {
+		o.PagingError(err)
+	}
+}
+
+// pagingEnd is where a paging method stopped: the cursor that continues after
+// the last item sent, and whether TikTok has more
+type pagingEnd struct {
+	Cursor  int
+	HasMore bool
+}
+
+// pagingEnded reports where paging stopped to the WithNextCursor handler
+func (o requestOptions) pagingEnded(end *pagingEnd) {
+	if o.NextCursor != nil {
+		o.NextCursor(end.Cursor, end.HasMore)
+	}
+}
+
+// pageError returns the error in a page of a paging method's results, if any
+func pageError(resp map[string]interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+	if resp == nil {
+		return errInvalidResponse
+	}
+	return CheckStatus(resp)
+}
+
+// call builds the API call for an endpoint, applying the per-request options
+func (o requestOptions) call(endpoint string, params map[string]string) apiCall {
+	for k, v := range o.Params {
+		if _, ok := params[k]; !ok {
+			params[k] = v
+		}
+	}
+	if o.MsToken != "" {
+		params["msToken"] = o.MsToken
+	}
+	return apiCall{
+		Endpoint:     endpoint,
+		Params:       params,
+		Headers:      o.Headers,
+		SessionIndex: o.SessionIndex,
+		Timeout:      o.Timeout,
+		CachePolicy:  o.CachePolicy,
+	}
+}
+
+// cursorFromResponse reads the next page cursor, which TikTok sends either as a number or a string
+func cursorFromResponse(resp map[string]interface{}) (int, bool) {
+	switch cursor := resp["cursor"].(type) {
+	case float64:
+		return int(cursor), true
+	case string:
+		parsed, err := strconv.Atoi(cursor)
+		if err != nil {
+			return 0, false
+		}
+		return parsed, true
+	}
+	return 0, false
+}
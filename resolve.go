@@ -0,0 +1,199 @@
+package ttscrape_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// EntityKind identifies the type of TikTok entity a URL points at
+type EntityKind string
+
+const (
+	KindSound    EntityKind = "sound"
+	KindVideo    EntityKind = "video"
+	KindUser     EntityKind = "user"
+	KindHashtag  EntityKind = "hashtag"
+	KindPlaylist EntityKind = "playlist"
+)
+
+// ErrShortLink is returned by ParseURL for short links that need a redirect to resolve
+var ErrShortLink = errors.New("short link must be resolved online")
+
+// ErrUnsupportedURL is returned for URLs that do not point at a known TikTok entity
+var ErrUnsupportedURL = errors.New("unsupported TikTok URL")
+
+// ParsedURL is a TikTok URL broken down into the entity it points at
+type ParsedURL struct {
+	Kind     EntityKind
+	ID       string // Numeric ID for sounds, videos and playlists
+	Username string // Username for users, and the owner for videos and playlists
+	Name     string // Hashtag name
+}
+
+// Path patterns of canonical TikTok URLs
+var (
+	soundPathRe    = regexp.MustCompile(`^/music/(?:.*-)?(\d+)/?$`)
+	videoPathRe    = regexp.MustCompile(`^/@([^/]+)/(?:video|photo)/(\d+)/?$`)
+	playlistPathRe = regexp.MustCompile(`^/@([^/]+)/(?:playlist|collection)/(?:.*-)?(\d+)/?$`)
+	userPathRe     = regexp.MustCompile(`^/@([^/]+)/?$`)
+	tagPathRe      = regexp.MustCompile(`^/tag/([^/]+)/?$`)
+	mobileVideoRe  = regexp.MustCompile(`^/v/(\d+)(?:\.html)?/?$`)
+	embedVideoRe   = regexp.MustCompile(`^/embed/(?:v2/)?(\d+)/?$`)
+)
+
+// ParseURL parses a canonical TikTok URL without making any requests.
+// Short links (vm.tiktok.com, vt.tiktok.com, tiktok.com/t/...) return ErrShortLink.
+func ParseURL(rawURL string) (ParsedURL, error) {
+	// Accept links pasted without a scheme
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return ParsedURL{}, fmt.Errorf("parse URL: %w", err)
+	}
+
+	host := strings.ToLower(parsedURL.Hostname())
+	if host != "tiktok.com" && !strings.HasSuffix(host, ".tiktok.com") {
+		return ParsedURL{}, fmt.Errorf("%w: %s is not a TikTok host", ErrUnsupportedURL, host)
+	}
+
+	if isShortLink(parsedURL) {
+		return ParsedURL{}, ErrShortLink
+	}
+
+	path := parsedURL.EscapedPath()
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+
+	if m := soundPathRe.FindStringSubmatch(path); m != nil {
+		return ParsedURL{Kind: KindSound, ID: m[1]}, nil
+	}
+	if m := videoPathRe.FindStringSubmatch(path); m != nil {
+		return ParsedURL{Kind: KindVideo, ID: m[2], Username: m[1]}, nil
+	}
+	if m := playlistPathRe.FindStringSubmatch(path); m != nil {
+		return ParsedURL{Kind: KindPlaylist, ID: m[2], Username: m[1]}, nil
+	}
+	if m := userPathRe.FindStringSubmatch(path); m != nil {
+		return ParsedURL{Kind: KindUser, Username: m[1]}, nil
+	}
+	if m := tagPathRe.FindStringSubmatch(path); m != nil {
+		return ParsedURL{Kind: KindHashtag, Name: m[1]}, nil
+	}
+	if m := mobileVideoRe.FindStringSubmatch(path); m != nil {
+		return ParsedURL{Kind: KindVideo, ID: m[1]}, nil
+	}
+	if m := embedVideoRe.FindStringSubmatch(path); m != nil {
+		return ParsedURL{Kind: KindVideo, ID: m[1]}, nil
+	}
+
+	return ParsedURL{}, fmt.Errorf("%w: %s", ErrUnsupportedURL, parsedURL.String())
+}
+
+// isShortLink reports whether u is a short link that redirects to a canonical URL
+func isShortLink(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if host == "vm.tiktok.com" || host == "vt.tiktok.com" {
+		return true
+	}
+	return strings.HasPrefix(u.Path, "/t/")
+}
+
+// Resolve returns the entity a TikTok URL points at, following short-link
+// redirects if needed. The result is a *Sound, *Video, *User, *Hashtag or *Playlist.
+// Short links are followed like any other request, through the session chosen
+// by the options and its proxy and rate limiter.
+func (api *TikTokAPI) Resolve(ctx context.Context, rawURL string, options ...RequestOption) (interface{}, error) {
+	parsed, err := ParseURL(rawURL)
+	if errors.Is(err, ErrShortLink) {
+		parsed, err = api.resolveShortLink(ctx, rawURL, newRequestOptions(options))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return api.Entity(parsed), nil
+}
+
+// Entity returns the entity handle for a parsed URL
+func (api *TikTokAPI) Entity(parsed ParsedURL) interface{} {
+	switch parsed.Kind {
+	case KindSound:
+		return api.Sound(parsed.ID)
+	case KindVideo:
+		video := api.Video(parsed.ID)
+		video.AuthorUsername = parsed.Username
+		return video
+	case KindUser:
+		return api.User(parsed.Username)
+	case KindHashtag:
+		return api.Hashtag(parsed.Name)
+	case KindPlaylist:
+		return api.Playlist(parsed.ID)
+	}
+	return nil
+}
+
+// maxShortLinkRedirects bounds the redirect chain followed for a short link
+const maxShortLinkRedirects = 10
+
+// resolveShortLink follows a short link's redirects until it reaches a canonical URL
+func (api *TikTokAPI) resolveShortLink(ctx context.Context, rawURL string, opts requestOptions) (ParsedURL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	call := opts.call(rawURL, map[string]string{})
+	call.PageScope = "short-link"
+	call.CachePolicy = CacheBypass
+	headers := make(map[string]string, len(call.Headers)+1)
+	for k, v := range call.Headers {
+		headers[k] = v
+	}
+	headers["Accept"] = "text/html,application/xhtml+xml,*/*"
+	call.Headers = headers
+
+	// Stop as soon as a redirect lands on a URL we can parse, so the page itself is never loaded
+	var parsed ParsedURL
+	last := rawURL
+	call.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if len(via) >= maxShortLinkRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxShortLinkRedirects)
+		}
+		last = next.URL.String()
+		if p, err := ParseURL(last); err == nil {
+			parsed = p
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+
+	err := api.request(ctx, call, func(r io.Reader) (responseStatus, error) {
+		return responseStatus{}, nil
+	})
+	if err != nil {
+		return ParsedURL{}, fmt.Errorf("follow short link: %w", err)
+	}
+
+	if parsed.Kind != "" {
+		return parsed, nil
+	}
+
+	// No redirect reached a canonical URL, report where the chain ended
+	parsed, err = ParseURL(last)
+	if err != nil {
+		return ParsedURL{}, fmt.Errorf("short link %s led to %s: %w", rawURL, last, err)
+	}
+	return parsed, nil
+}
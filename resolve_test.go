@@ -0,0 +1,135 @@
+package ttscrape_go_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+func TestParseURL(t *testing.T) {
+	for _, tt := range []struct {
+		url  string
+		want ttscrape_go.ParsedURL
+	}{
+		{"https://www.tiktok.com/music/original-sound-6770603781966039810",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindSound, ID: "6770603781966039810"}},
+		{"https://www.tiktok.com/music/Song-2-Remix-7000000000000000001?lang=en",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindSound, ID: "7000000000000000001"}},
+		{"https://www.tiktok.com/music/7000000000000000002/",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindSound, ID: "7000000000000000002"}},
+		{"https://www.tiktok.com/@some.user/video/7100000000000000001?is_from_webapp=1",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindVideo, ID: "7100000000000000001", Username: "some.user"}},
+		{"tiktok.com/@some.user/photo/7100000000000000002",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindVideo, ID: "7100000000000000002", Username: "some.user"}},
+		{"https://www.tiktok.com/@some.user/playlist/Best-Of-7200000000000000001",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindPlaylist, ID: "7200000000000000001", Username: "some.user"}},
+		{"https://www.tiktok.com/@some.user/collection/Saved-7200000000000000002",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindPlaylist, ID: "7200000000000000002", Username: "some.user"}},
+		{"https://www.tiktok.com/@some.user",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindUser, Username: "some.user"}},
+		{" www.tiktok.com/@some.user/ ",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindUser, Username: "some.user"}},
+		{"https://www.tiktok.com/tag/fyp",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindHashtag, Name: "fyp"}},
+		{"https://www.tiktok.com/tag/%E6%97%A5%E6%9C%AC",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindHashtag, Name: "日本"}},
+		{"https://m.tiktok.com/v/7100000000000000003.html",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindVideo, ID: "7100000000000000003"}},
+		{"https://www.tiktok.com/v/7100000000000000004",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindVideo, ID: "7100000000000000004"}},
+		{"https://www.tiktok.com/embed/v2/7100000000000000005",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindVideo, ID: "7100000000000000005"}},
+		{"https://www.tiktok.com/embed/7100000000000000006",
+			ttscrape_go.ParsedURL{Kind: ttscrape_go.KindVideo, ID: "7100000000000000006"}},
+	} {
+		got, err := ttscrape_go.ParseURL(tt.url)
+		if err != nil {
+			t.Errorf("ParseURL(%q): %v", tt.url, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseURL(%q) = %+v, want %+v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestParseURLErrors(t *testing.T) {
+	for url, want := range map[string]error{
+		"https://vm.tiktok.com/ZMabc123/":  ttscrape_go.ErrShortLink,
+		"vt.tiktok.com/ZSabc123":           ttscrape_go.ErrShortLink,
+		"https://www.tiktok.com/t/ZTabc/":  ttscrape_go.ErrShortLink,
+		"https://www.tiktok.com/foryou":    ttscrape_go.ErrUnsupportedURL,
+		"https://www.youtube.com/@someone": ttscrape_go.ErrUnsupportedURL,
+		"https://nottiktok.com/@someone":   ttscrape_go.ErrUnsupportedURL,
+	} {
+		if _, err := ttscrape_go.ParseURL(url); !errors.Is(err, want) {
+			t.Errorf("ParseURL(%q): %v, want %v", url, err, want)
+		}
+	}
+}
+
+// newShortLinkStandin returns an API whose requests to any host reach srv
+func newShortLinkStandin(t *testing.T) (*ttscrape_go.TikTokAPI, *ttscrapetest.Server) {
+	t.Helper()
+	api, srv := newStandin(t)
+	api.SetHTTPClient(&http.Client{Transport: srv.Transport()})
+	return api, srv
+}
+
+func TestResolveShortLink(t *testing.T) {
+	api, srv := newShortLinkStandin(t)
+	srv.AddShortLink("ZTvideo", "https://www.tiktok.com/@some.user/video/7100000000000000001")
+	srv.AddShortLink("ZThop", "https://www.tiktok.com/t/ZTvideo/")
+	srv.AddShortLink("ZTloop", "https://www.tiktok.com/t/ZTloop/")
+
+	// A redirect to a canonical URL, directly or through another short link
+	for _, link := range []string{"https://www.tiktok.com/t/ZTvideo/", "www.tiktok.com/t/ZThop"} {
+		entity, err := api.Resolve(context.Background(), link)
+		if err != nil {
+			t.Errorf("Resolve(%q): %v", link, err)
+			continue
+		}
+		video, ok := entity.(*ttscrape_go.Video)
+		if !ok || video.ID != "7100000000000000001" || video.AuthorUsername != "some.user" {
+			t.Errorf("Resolve(%q) = %#v, want video 7100000000000000001 by some.user", link, entity)
+		}
+	}
+
+	// The canonical page itself is never requested
+	if n := srv.Requests("/@some.user/video/7100000000000000001"); n != 0 {
+		t.Errorf("canonical page requested %d times", n)
+	}
+
+	if _, err := api.Resolve(context.Background(), "https://www.tiktok.com/t/ZTloop/"); err == nil {
+		t.Error("Resolve of a redirect loop succeeded")
+	}
+	if _, err := api.Resolve(context.Background(), "https://www.tiktok.com/t/ZTmissing/"); err == nil {
+		t.Error("Resolve of an unknown short link succeeded")
+	}
+}
+
+func TestResolveShortLinkSession(t *testing.T) {
+	api, srv := newShortLinkStandin(t)
+	srv.AddShortLink("ZTvideo", "https://www.tiktok.com/@some.user/video/7100000000000000001")
+	link := "https://www.tiktok.com/t/ZTvideo/"
+
+	// Short links go through the chosen session
+	if _, err := api.Resolve(context.Background(), link, ttscrape_go.WithSession(3)); !errors.Is(err, ttscrape_go.ErrSessionIndex) {
+		t.Errorf("Resolve through a missing session: %v, want ErrSessionIndex", err)
+	}
+
+	// And are refused once the API shuts down, while canonical URLs still parse
+	if err := api.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.Resolve(context.Background(), link); !errors.Is(err, ttscrape_go.ErrShutdown) {
+		t.Errorf("Resolve after shutdown: %v, want ErrShutdown", err)
+	}
+	if _, err := api.Resolve(context.Background(), "https://www.tiktok.com/tag/fyp"); err != nil {
+		t.Errorf("Resolve of a canonical URL after shutdown: %v", err)
+	}
+}
@@ -0,0 +1,458 @@
+// Package server exposes a TikTokAPI session pool as a JSON REST service, so
+// other services can fetch TikTok data without managing msTokens or browsers.
+//
+// Routes:
+//
+//	GET /sounds/{id}
+//	GET /sounds/{id}/videos?count=&cursor=
+//	GET /videos/{id}
+//	GET /users/{username}
+//	GET /users/{username}/playlists?count=&cursor=
+//	GET /hashtags/{name}
+//	GET /hashtags/{name}/videos?count=&cursor=
+//	GET /playlists/{id}
+//	GET /playlists/{id}/videos?count=&cursor=
+//	GET /trending?count=
+//	GET /healthz
+//	GET /readyz
+//
+// Errors are returned as {"error": "...", "statusCode": <TikTok status>} with
+// an HTTP status mapped from the TikTok status: 404 for missing entities, 403
+// for private ones, 429 when TikTok rate limits the sessions, 503 when the
+// request times out or the API shuts down, and 502 for other TikTok errors and
+// unusable responses. A listing that fails part way returns the error rather
+// than a partial list. Listings with a cursor also return the cursor to resume
+// at and whether TikTok has more items, as {"cursor": c, "hasMore": b}.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+)
+
+// Defaults for Options fields left at zero
+const (
+	defaultCount    = 30
+	defaultMaxCount = 500
+	defaultTimeout  = 60 * time.Second
+)
+
+// TikTok status codes with a more specific HTTP status than 502
+var tiktokStatuses = map[int]int{
+	10201: http.StatusNotFound,  // Video not found
+	10202: http.StatusNotFound,  // User not found
+	10204: http.StatusNotFound,  // Video removed
+	10205: http.StatusNotFound,  // Hashtag not found
+	10218: http.StatusNotFound,  // Sound not found
+	10216: http.StatusForbidden, // Private video
+	10222: http.StatusForbidden, // Private account
+}
+
+// Request validation patterns
+var (
+	idPattern       = regexp.MustCompile(`^[0-9]{1,25}$`)
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._]{1,24}$`)
+	hashtagPattern  = regexp.MustCompile(`^[^/\s#?]{1,100}$`)
+)
+
+// Options configures a Server
+type Options struct {
+	MaxCount int           // Largest count a listing may ask for, 500 if 0
+	Timeout  time.Duration // Time limit per request, 60s if 0
+}
+
+// Server serves TikTok data from a TikTokAPI. Requests are spread across the
+// API's sessions round-robin.
+type Server struct {
+	api   *ttscrape_go.TikTokAPI
+	opts  Options
+	next  atomic.Uint64
+	ready atomic.Bool
+}
+
+// New creates a server for api. The server reports itself not ready until
+// SetReady(true) is called, so sessions can be created after it starts listening.
+func New(api *ttscrape_go.TikTokAPI, opts Options) *Server {
+	if opts.MaxCount <= 0 {
+		opts.MaxCount = defaultMaxCount
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	return &Server{api: api, opts: opts}
+}
+
+// SetReady marks the server ready or not ready to serve TikTok data
+func (s *Server) SetReady(ready bool) {
+	s.ready.Store(ready)
+}
+
+// Handler returns the HTTP handler serving every route
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /readyz", s.handleReady)
+
+	data := http.NewServeMux()
+	data.HandleFunc("GET /sounds/{id}", s.handleSound)
+	data.HandleFunc("GET /sounds/{id}/videos", s.handleSoundVideos)
+	data.HandleFunc("GET /videos/{id}", s.handleVideo)
+	data.HandleFunc("GET /users/{username}", s.handleUser)
+	data.HandleFunc("GET /users/{username}/playlists", s.handleUserPlaylists)
+	data.HandleFunc("GET /hashtags/{name}", s.handleHashtag)
+	data.HandleFunc("GET /hashtags/{name}/videos", s.handleHashtagVideos)
+	data.HandleFunc("GET /playlists/{id}", s.handlePlaylist)
+	data.HandleFunc("GET /playlists/{id}/videos", s.handlePlaylistVideos)
+	data.HandleFunc("GET /trending", s.handleTrending)
+
+	timeoutBody, _ := json.Marshal(errorBody{Error: "request timed out"})
+	mux.Handle("/", http.TimeoutHandler(s.requireReady(data), s.opts.Timeout, string(timeoutBody)))
+
+	return mux
+}
+
+// errorBody is the JSON body of an error response
+type errorBody struct {
+	Error      string `json:"error"`
+	StatusCode int    `json:"statusCode,omitempty"` // TikTok status, when TikTok reported the error
+}
+
+// requireReady rejects requests until the server is ready
+func (s *Server) requireReady(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.ready.Load() || s.api.SessionCount() == 0 {
+			writeError(w, http.StatusServiceUnavailable, errors.New("no TikTok sessions available"), 0)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleHealth reports that the process is serving
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+}
+
+// handleReady reports whether sessions are available
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	// Sessions may still be being created until the server is ready
+	if !s.ready.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "not ready"})
+		return
+	}
+
+	sessions := s.api.SessionCount()
+	if sessions == 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "not ready", "sessions": 0})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ready", "sessions": sessions})
+}
+
+// handleSound implements GET /sounds/{id}
+func (s *Server) handleSound(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathValue(w, r, "id", idPattern)
+	if !ok {
+		return
+	}
+	s.writeInfo(w, r, func(ctx context.Context, options ...ttscrape_go.RequestOption) (map[string]interface{}, error) {
+		return s.api.Sound(id).Info(ctx, options...)
+	})
+}
+
+// handleSoundVideos implements GET /sounds/{id}/videos
+func (s *Server) handleSoundVideos(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathValue(w, r, "id", idPattern)
+	if !ok {
+		return
+	}
+	count, cursor, ok := s.paging(w, r)
+	if !ok {
+		return
+	}
+
+	var end listEnd
+	videos, err := s.api.Sound(id).Videos(r.Context(), count, cursor, s.listOptions(&end)...)
+	if err != nil {
+		writeTikTokError(w, err)
+		return
+	}
+	writeList(w, "videos", videos, &end, func(video map[string]interface{}) interface{} { return video })
+}
+
+// handleVideo implements GET /videos/{id}
+func (s *Server) handleVideo(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathValue(w, r, "id", idPattern)
+	if !ok {
+		return
+	}
+	s.writeInfo(w, r, func(ctx context.Context, options ...ttscrape_go.RequestOption) (map[string]interface{}, error) {
+		return s.api.Video(id).Info(ctx, options...)
+	})
+}
+
+// handleUser implements GET /users/{username}
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	username, ok := pathValue(w, r, "username", usernamePattern)
+	if !ok {
+		return
+	}
+	s.writeInfo(w, r, func(ctx context.Context, options ...ttscrape_go.RequestOption) (map[string]interface{}, error) {
+		return s.api.User(username).Info(ctx, options...)
+	})
+}
+
+// handleUserPlaylists implements GET /users/{username}/playlists
+func (s *Server) handleUserPlaylists(w http.ResponseWriter, r *http.Request) {
+	username, ok := pathValue(w, r, "username", usernamePattern)
+	if !ok {
+		return
+	}
+	count, cursor, ok := s.paging(w, r)
+	if !ok {
+		return
+	}
+
+	var end listEnd
+	playlists, err := s.api.User(username).Playlists(r.Context(), count, cursor, s.listOptions(&end)...)
+	if err != nil {
+		writeTikTokError(w, err)
+		return
+	}
+	writeList(w, "playlists", playlists, &end, func(playlist *ttscrape_go.Playlist) interface{} { return playlist.AsDict })
+}
+
+// handleHashtag implements GET /hashtags/{name}
+func (s *Server) handleHashtag(w http.ResponseWriter, r *http.Request) {
+	name, ok := pathValue(w, r, "name", hashtagPattern)
+	if !ok {
+		return
+	}
+	s.writeInfo(w, r, func(ctx context.Context, options ...ttscrape_go.RequestOption) (map[string]interface{}, error) {
+		return s.api.Hashtag(name).Info(ctx, options...)
+	})
+}
+
+// handleHashtagVideos implements GET /hashtags/{name}/videos
+func (s *Server) handleHashtagVideos(w http.ResponseWriter, r *http.Request) {
+	name, ok := pathValue(w, r, "name", hashtagPattern)
+	if !ok {
+		return
+	}
+	count, cursor, ok := s.paging(w, r)
+	if !ok {
+		return
+	}
+
+	var end listEnd
+	videos, err := s.api.Hashtag(name).Videos(r.Context(), count, cursor, s.listOptions(&end)...)
+	if err != nil {
+		writeTikTokError(w, err)
+		return
+	}
+	writeList(w, "videos", videos, &end, videoJSON)
+}
+
+// handlePlaylist implements GET /playlists/{id}
+func (s *Server) handlePlaylist(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathValue(w, r, "id", idPattern)
+	if !ok {
+		return
+	}
+	s.writeInfo(w, r, func(ctx context.Context, options ...ttscrape_go.RequestOption) (map[string]interface{}, error) {
+		return s.api.Playlist(id).Info(ctx, options...)
+	})
+}
+
+// handlePlaylistVideos implements GET /playlists/{id}/videos
+func (s *Server) handlePlaylistVideos(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathValue(w, r, "id", idPattern)
+	if !ok {
+		return
+	}
+	count, cursor, ok := s.paging(w, r)
+	if !ok {
+		return
+	}
+
+	var end listEnd
+	videos, err := s.api.Playlist(id).Videos(r.Context(), count, cursor, s.listOptions(&end)...)
+	if err != nil {
+		writeTikTokError(w, err)
+		return
+	}
+	writeList(w, "videos", videos, &end, videoJSON)
+}
+
+// handleTrending implements GET /trending
+func (s *Server) handleTrending(w http.ResponseWriter, r *http.Request) {
+	count, _, ok := s.paging(w, r)
+	if !ok {
+		return
+	}
+
+	var end listEnd
+	videos, err := s.api.Trending(r.Context(), count, s.listOptions(&end)...)
+	if err != nil {
+		writeTikTokError(w, err)
+		return
+	}
+	writeList(w, "videos", videos, &end, videoJSON)
+}
+
+// writeInfo runs an Info call and writes its response, mapping TikTok errors
+// to HTTP statuses
+func (s *Server) writeInfo(w http.ResponseWriter, r *http.Request, info func(ctx context.Context, options ...ttscrape_go.RequestOption) (map[string]interface{}, error)) {
+	resp, err := info(r.Context(), s.options()...)
+	if err == nil {
+		err = ttscrape_go.CheckStatus(resp)
+	}
+	if err != nil {
+		writeTikTokError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeTikTokError writes the error of a TikTok call, mapping TikTok statuses,
+// rate limiting, shutdown and cancellation to HTTP statuses and anything else
+// to 502
+func writeTikTokError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ttscrape_go.ErrShutdown) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		writeError(w, http.StatusServiceUnavailable, err, 0)
+		return
+	}
+
+	var httpErr *ttscrape_go.HTTPStatusError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests {
+		writeError(w, http.StatusTooManyRequests, err, 0)
+		return
+	}
+
+	var statusErr *ttscrape_go.StatusError
+	if !errors.As(err, &statusErr) {
+		writeError(w, http.StatusBadGateway, err, 0)
+		return
+	}
+
+	status, ok := tiktokStatuses[statusErr.Code]
+	if !ok {
+		status = http.StatusBadGateway
+	}
+	writeError(w, status, err, statusErr.Code)
+}
+
+// options returns request options for the next session in round-robin order
+func (s *Server) options() []ttscrape_go.RequestOption {
+	indexes := s.api.SessionIndexes()
+	index := 0
+	if len(indexes) > 0 {
+		index = indexes[(s.next.Add(1)-1)%uint64(len(indexes))]
+	}
+	return []ttscrape_go.RequestOption{ttscrape_go.WithSession(index)}
+}
+
+// listEnd is how the paging of a listing ended
+type listEnd struct {
+	err     error // Error that ended paging early
+	cursor  int   // Cursor to resume the listing at
+	hasMore bool  // Whether TikTok has more items after cursor
+	paged   bool  // Whether cursor and hasMore were reported, Trending has no cursor
+}
+
+// listOptions returns the options of a listing: those of options, and
+// handlers storing how paging ended in end
+func (s *Server) listOptions(end *listEnd) []ttscrape_go.RequestOption {
+	return append(s.options(),
+		ttscrape_go.WithPagingError(func(err error) { end.err = err }),
+		ttscrape_go.WithNextCursor(func(cursor int, hasMore bool) {
+			end.cursor, end.hasMore, end.paged = cursor, hasMore, true
+		}),
+	)
+}
+
+// paging validates the count and cursor query parameters
+func (s *Server) paging(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	count, err := queryInt(r, "count", defaultCount)
+	if err != nil || count < 1 || count > s.opts.MaxCount {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("count must be between 1 and %d", s.opts.MaxCount), 0)
+		return 0, 0, false
+	}
+
+	cursor, err := queryInt(r, "cursor", 0)
+	if err != nil || cursor < 0 {
+		writeError(w, http.StatusBadRequest, errors.New("cursor must be a non-negative integer"), 0)
+		return 0, 0, false
+	}
+
+	return count, cursor, true
+}
+
+// queryInt parses an integer query parameter, returning def if it is absent
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// pathValue returns a path parameter after checking it against pattern
+func pathValue(w http.ResponseWriter, r *http.Request, name string, pattern *regexp.Regexp) (string, bool) {
+	value := r.PathValue(name)
+	if !pattern.MatchString(value) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s %q", name, value), 0)
+		return "", false
+	}
+	return value, true
+}
+
+// videoJSON returns the item struct of a video
+func videoJSON(video *ttscrape_go.Video) interface{} {
+	return video.AsDict
+}
+
+// writeList drains items and writes them as {"<key>": [...], "count": n,
+// "cursor": c, "hasMore": b}, or the error in end if paging ended early
+func writeList[T any](w http.ResponseWriter, key string, items <-chan T, end *listEnd, toJSON func(T) interface{}) {
+	all := make([]interface{}, 0)
+	for item := range items {
+		all = append(all, toJSON(item))
+	}
+	if end.err != nil {
+		writeTikTokError(w, end.err)
+		return
+	}
+	body := map[string]interface{}{
+		key:     all,
+		"count": len(all),
+	}
+	if end.paged {
+		body["cursor"] = end.cursor
+		body["hasMore"] = end.hasMore
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// writeError writes an error response
+func writeError(w http.ResponseWriter, status int, err error, tiktokStatus int) {
+	writeJSON(w, status, errorBody{Error: err.Error(), StatusCode: tiktokStatus})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
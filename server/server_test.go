@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// newTestServer returns a ready Server backed by a stand-in serving sound 1
+func newTestServer(t *testing.T) (*Server, *ttscrapetest.Server) {
+	t.Helper()
+	srv := ttscrapetest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddSound("1", ttscrapetest.SyntheticFixture("1", 45))
+	srv.SetPageSize(10)
+
+	api, err := srv.NewAPI(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { api.Close() })
+
+	s := New(api, Options{})
+	s.SetReady(true)
+	return s, srv
+}
+
+// get serves a GET request and decodes the JSON response
+func get(t *testing.T, s *Server, path string) (int, map[string]interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("GET %s: %v in %q", path, err, rec.Body.String())
+	}
+	return rec.Code, body
+}
+
+func TestSound(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	if status, _ := get(t, s, "/sounds/1"); status != http.StatusOK {
+		t.Errorf("GET /sounds/1: status %d", status)
+	}
+
+	status, body := get(t, s, "/sounds/2")
+	if status != http.StatusNotFound || body["statusCode"] != float64(ttscrapetest.StatusNotFound) {
+		t.Errorf("GET /sounds/2: status %d, body %v", status, body)
+	}
+}
+
+func TestSoundVideos(t *testing.T) {
+	s, srv := newTestServer(t)
+
+	status, body := get(t, s, "/sounds/1/videos?count=25")
+	if status != http.StatusOK || body["count"] != float64(25) {
+		t.Errorf("GET /sounds/1/videos: status %d, count %v", status, body["count"])
+	}
+	if body["cursor"] != float64(25) || body["hasMore"] != true {
+		t.Errorf("GET /sounds/1/videos: cursor %v, hasMore %v, want 25, true", body["cursor"], body["hasMore"])
+	}
+
+	// Resuming at the returned cursor lists the rest
+	status, body = get(t, s, "/sounds/1/videos?count=100&cursor=25")
+	if status != http.StatusOK || body["count"] != float64(20) || body["cursor"] != float64(45) || body["hasMore"] != false {
+		t.Errorf("GET /sounds/1/videos?cursor=25: status %d, count %v, cursor %v, hasMore %v", status, body["count"], body["cursor"], body["hasMore"])
+	}
+
+	// An unknown sound is a 404, not an empty list
+	status, body = get(t, s, "/sounds/2/videos")
+	if status != http.StatusNotFound || body["statusCode"] != float64(ttscrapetest.StatusNotFound) {
+		t.Errorf("GET /sounds/2/videos: status %d, body %v", status, body)
+	}
+
+	// So is a failed page
+	srv.SetFault(ttscrapetest.PathMusicItemList, ttscrapetest.Fault{Captcha: true})
+	if status, body = get(t, s, "/sounds/1/videos"); status != http.StatusBadGateway {
+		t.Errorf("GET /sounds/1/videos with a captcha: status %d, body %v", status, body)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	for _, path := range []string{
+		"/sounds/abc",
+		"/sounds/1/videos?count=0",
+		"/sounds/1/videos?count=501",
+		"/sounds/1/videos?cursor=-1",
+		"/users/bad%20name",
+	} {
+		if status, _ := get(t, s, path); status != http.StatusBadRequest {
+			t.Errorf("GET %s: status %d, want 400", path, status)
+		}
+	}
+}
+
+func TestReady(t *testing.T) {
+	s, _ := newTestServer(t)
+	s.SetReady(false)
+
+	if status, _ := get(t, s, "/readyz"); status != http.StatusServiceUnavailable {
+		t.Errorf("GET /readyz: status %d, want 503", status)
+	}
+	if status, _ := get(t, s, "/sounds/1"); status != http.StatusServiceUnavailable {
+		t.Errorf("GET /sounds/1 before ready: status %d, want 503", status)
+	}
+
+	s.SetReady(true)
+	if status, _ := get(t, s, "/readyz"); status != http.StatusOK {
+		t.Errorf("GET /readyz: status %d, want 200", status)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	s, srv := newTestServer(t)
+
+	srv.SetFault(ttscrapetest.PathMusicDetail, ttscrapetest.Fault{StatusCode: http.StatusTooManyRequests})
+	if status, body := get(t, s, "/sounds/1"); status != http.StatusTooManyRequests {
+		t.Errorf("GET /sounds/1 while rate limited: status %d, body %v", status, body)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	if err := s.api.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/sounds/1", "/sounds/1/videos"} {
+		if status, body := get(t, s, path); status != http.StatusServiceUnavailable {
+			t.Errorf("GET %s after shutdown: status %d, body %v", path, status, body)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package ttscrape_go
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// ErrSessionIndex is returned when a call names a session that is not in the pool,
+// for example because it was removed while a paging goroutine was using it
+var ErrSessionIndex = errors.New("session index out of range")
+
+// Sessions returns a snapshot of the session pool. Session i of the snapshot
+// is the one selected by WithSession(i); removed sessions are nil, so the
+// indexes of the others never change.
+func (api *TikTokAPI) Sessions() []*TikTokSession {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
+	return slices.Clone(api.sessions)
+}
+
+// SessionCount returns the number of sessions in the pool, not counting removed ones
+func (api *TikTokAPI) SessionCount() int {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
+	return api.activeSessions()
+}
+
+// SessionIndexes returns the indexes of the sessions in the pool, in order,
+// skipping removed ones. Callers spreading requests across sessions should
+// pick from these rather than from 0 to SessionCount.
+func (api *TikTokAPI) SessionIndexes() []int {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
+	indexes := make([]int, 0, len(api.sessions))
+	for i, session := range api.sessions {
+		if session != nil {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// activeSessions counts the sessions that have not been removed, the caller must hold api.mu
+func (api *TikTokAPI) activeSessions() int {
+	active := 0
+	for _, session := range api.sessions {
+		if session != nil {
+			active++
+		}
+	}
+	return active
+}
+
+// AddSession creates a session and appends it to the pool, returning its
+// index. With BrowserFree set and an msToken the session is browser-free from
+// the start; otherwise a browser is opened and left to settle for sleepAfter
+// seconds. It may be called while requests are in flight.
+func (api *TikTokAPI) AddSession(ctx context.Context, msToken string, sleepAfter int) (int, error) {
+	return api.addSession(ctx, msToken, sleepAfter, api.BrowserFree && msToken != "")
+}
+
+// addSession creates a browser-free or browser session and appends it to the pool
+func (api *TikTokAPI) addSession(ctx context.Context, msToken string, sleepAfter int, browserFree bool) (int, error) {
+	if api.shuttingDown() {
+		return -1, ErrShutdown
+	}
+
+	// Proxies are assigned by index, counting removed sessions
+	api.mu.RLock()
+	next := len(api.sessions)
+	api.mu.RUnlock()
+	proxy := api.sessionProxy(next)
+
+	var session *TikTokSession
+	var err error
+	if browserFree {
+		session, err = api.newBrowserFreeSession(msToken, proxy)
+	} else {
+		session, err = api.createSession(ctx, api.sessionBaseURL(), msToken, proxy, sleepAfter)
+	}
+	if err != nil {
+		api.logger().Error("session creation failed", "session_index", next, "error", err)
+		return -1, err
+	}
+
+	// The limiter is created under the lock so it sees the current rate limit.
+	// A session finished after shutdown started would never be closed, so it
+	// is closed here instead.
+	api.mu.Lock()
+	if api.shuttingDown() {
+		api.mu.Unlock()
+		session.close()
+		return -1, ErrShutdown
+	}
+	session.limiter = api.newLimiter()
+	api.sessions = append(api.sessions, session)
+	index := len(api.sessions) - 1
+	api.metrics().SetActiveSessions(api.activeSessions())
+	api.mu.Unlock()
+
+	api.logger().Info("session created", "session_index", index, "browser_free", session.BrowserFree)
+	return index, nil
+}
+
+// RemoveSession removes the session at index from the pool and closes its
+// browser. Its slot is left empty, so other sessions keep their indexes and
+// new ones are appended after it. Requests already running on the session
+// finish; later calls that name it fail with ErrSessionIndex. It may be
+// called while requests are in flight.
+func (api *TikTokAPI) RemoveSession(index int) error {
+	api.mu.Lock()
+	if index < 0 || index >= len(api.sessions) || api.sessions[index] == nil {
+		api.mu.Unlock()
+		return ErrSessionIndex
+	}
+	session := api.sessions[index]
+	api.sessions[index] = nil
+	api.metrics().SetActiveSessions(api.activeSessions())
+	api.mu.Unlock()
+
+	session.close()
+	api.logger().Info("session removed", "session_index", index)
+	return nil
+}
+
+// session returns the session at index
+func (api *TikTokAPI) session(index int) (*TikTokSession, error) {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
+	if index < 0 || index >= len(api.sessions) || api.sessions[index] == nil {
+		return nil, ErrSessionIndex
+	}
+	return api.sessions[index], nil
+}
+
+// close closes the session's browser, if it has one
+func (s *TikTokSession) close() {
+	if !s.BrowserFree && s.CancelFunc != nil {
+		s.CancelFunc()
+	}
+}
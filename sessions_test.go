@@ -0,0 +1,130 @@
+package ttscrape_go_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/metrics"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+func TestRemoveSession(t *testing.T) {
+	srv := ttscrapetest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddSound("1", ttscrapetest.SyntheticFixture("1", 10))
+
+	api, err := srv.NewAPI(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { api.Close() })
+	ctx := context.Background()
+	third := api.Sessions()[2]
+
+	if err := api.RemoveSession(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.RemoveSession(1); !errors.Is(err, ttscrape_go.ErrSessionIndex) {
+		t.Errorf("removing session 1 twice returned %v, want ErrSessionIndex", err)
+	}
+
+	// The other sessions keep their indexes
+	if n := api.SessionCount(); n != 2 {
+		t.Errorf("SessionCount = %d, want 2", n)
+	}
+	if indexes := api.SessionIndexes(); !slices.Equal(indexes, []int{0, 2}) {
+		t.Errorf("SessionIndexes = %v, want [0 2]", indexes)
+	}
+	sessions := api.Sessions()
+	if len(sessions) != 3 || sessions[1] != nil || sessions[2] != third {
+		t.Errorf("Sessions after removing session 1 = %v", sessions)
+	}
+
+	if _, err := api.Sound("1").Info(ctx, ttscrape_go.WithSession(1)); !errors.Is(err, ttscrape_go.ErrSessionIndex) {
+		t.Errorf("Info on the removed session returned %v, want ErrSessionIndex", err)
+	}
+	if _, err := api.Sound("1").Info(ctx, ttscrape_go.WithSession(2)); err != nil {
+		t.Errorf("Info on session 2: %v", err)
+	}
+
+	// New sessions are appended after the removed slot
+	index, err := api.AddSession(ctx, "test-token", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != 3 {
+		t.Errorf("AddSession returned index %d, want 3", index)
+	}
+}
+
+func TestSessionsWhilePaging(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.SetPageSize(2)
+	srv.SetFault(ttscrapetest.PathMusicItemList, ttscrapetest.Fault{Delay: 5 * time.Millisecond})
+	ctx := context.Background()
+
+	videos, err := api.Sound("1").Videos(ctx, 10, 0, ttscrape_go.WithSession(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sessions come and go and metrics are swapped while session 0 pages
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			index, err := api.AddSession(ctx, "test-token", 0)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			api.Sessions()
+			api.SessionIndexes()
+			if _, err := api.Sound("1").Info(ctx, ttscrape_go.WithSession(index)); err != nil && !errors.Is(err, ttscrape_go.ErrSessionIndex) {
+				t.Errorf("Info on session %d: %v", index, err)
+			}
+			if err := api.RemoveSession(index); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			previous := api.SwapMetrics(metrics.NewPrometheus())
+			api.SetMetrics(previous)
+		}
+	}()
+
+	received := 0
+	for range videos {
+		received++
+	}
+	close(stop)
+	wg.Wait()
+
+	if received != 10 {
+		t.Errorf("received %d videos, want 10", received)
+	}
+	if n := api.SessionCount(); n != 1 {
+		t.Errorf("SessionCount = %d, want 1", n)
+	}
+}
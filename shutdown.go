@@ -0,0 +1,170 @@
+package ttscrape_go
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShutdown is returned for requests, paging and sessions started after
+// Shutdown or Close
+var ErrShutdown = errors.New("API is shut down")
+
+// lifecycle tracks the requests and paging goroutines running on a TikTokAPI
+// so Shutdown can wait for them
+type lifecycle struct {
+	once    sync.Once
+	mu      sync.Mutex
+	closed  bool
+	active  int                        // Requests and paging goroutines still running
+	idle    chan struct{}              // Closed when active drops to 0 while Shutdown waits
+	stop    chan struct{}              // Closed when shutdown starts; paging goroutines return
+	aborted bool                       // Set once in-flight requests must give up
+	cancels map[int]context.CancelFunc // Cancel the contexts of in-flight requests
+	nextID  int
+}
+
+// lifecycle returns the API's lifecycle, initialising it on first use
+func (api *TikTokAPI) lifecycle() *lifecycle {
+	api.life.once.Do(func() {
+		api.life.stop = make(chan struct{})
+		api.life.cancels = map[int]context.CancelFunc{}
+	})
+	return &api.life
+}
+
+// begin registers a request or paging goroutine, returning the function that
+// marks it finished, or ErrShutdown once shutdown has started
+func (api *TikTokAPI) begin() (func(), error) {
+	l := api.lifecycle()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil, ErrShutdown
+	}
+	l.active++
+	return sync.OnceFunc(l.finish), nil
+}
+
+// finish marks a request or paging goroutine finished, waking Shutdown after the last one
+func (l *lifecycle) finish() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	if l.active == 0 && l.idle != nil {
+		close(l.idle)
+		l.idle = nil
+	}
+}
+
+// shuttingDown reports whether Shutdown or Close has been called
+func (api *TikTokAPI) shuttingDown() bool {
+	l := api.lifecycle()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.closed
+}
+
+// beginPaging registers a paging goroutine. The returned channel is closed when
+// shutdown starts; the goroutine must stop sending then and call done.
+func (api *TikTokAPI) beginPaging() (<-chan struct{}, func(), error) {
+	done, err := api.begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	return api.lifecycle().stop, done, nil
+}
+
+// abortable returns a context that is also cancelled when Close is called or
+// Shutdown gives up waiting
+func (api *TikTokAPI) abortable(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	l := api.lifecycle()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.aborted {
+		cancel()
+		return ctx, cancel
+	}
+	id := l.nextID
+	l.nextID++
+	l.cancels[id] = cancel
+
+	return ctx, func() {
+		l.mu.Lock()
+		delete(l.cancels, id)
+		l.mu.Unlock()
+		cancel()
+	}
+}
+
+// abort cancels every in-flight request and any started later
+func (l *lifecycle) abort() {
+	l.mu.Lock()
+	l.aborted = true
+	cancels := l.cancels
+	l.cancels = map[int]context.CancelFunc{}
+	l.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+// stopAccepting marks the API as shut down and tells paging goroutines to stop
+func (api *TikTokAPI) stopAccepting() {
+	l := api.lifecycle()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.closed {
+		l.closed = true
+		close(l.stop)
+	}
+}
+
+// Shutdown gracefully shuts the API down. New requests, paging and sessions
+// fail with ErrShutdown straight away, and paging goroutines stop at their
+// next page or blocked send and close their channels. Requests already in
+// flight are left to finish until ctx is done, when they are cancelled.
+// Shutdown then waits for everything to return, closes every session and its
+// browser, and returns ctx.Err() if the requests had to be cancelled.
+func (api *TikTokAPI) Shutdown(ctx context.Context) error {
+	api.stopAccepting()
+	l := api.lifecycle()
+
+	var err error
+	if idle := l.waitIdle(); idle != nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			err = ctx.Err()
+			l.abort()
+			<-idle
+		}
+	}
+
+	api.closeSessions()
+	api.logger().Debug("shutdown complete", "cancelled", err != nil)
+	return err
+}
+
+// waitIdle returns a channel that is closed once no requests or paging
+// goroutines are running, or nil if none are
+func (l *lifecycle) waitIdle() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active == 0 {
+		return nil
+	}
+	if l.idle == nil {
+		l.idle = make(chan struct{})
+	}
+	return l.idle
+}
@@ -0,0 +1,121 @@
+package ttscrape_go_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// infoResult starts Info on sound 1 and returns its eventual error once the
+// stand-in has received the request
+func infoResult(t *testing.T, api *ttscrape_go.TikTokAPI, srv *ttscrapetest.Server) <-chan error {
+	t.Helper()
+	result := make(chan error, 1)
+	go func() {
+		_, err := api.Sound("1").Info(context.Background())
+		result <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.Requests(ttscrapetest.PathMusicDetail) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("request never reached the stand-in")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return result
+}
+
+func TestShutdownWaitsForRequests(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.SetFault(ttscrapetest.PathMusicDetail, ttscrapetest.Fault{Delay: 100 * time.Millisecond})
+
+	result := infoResult(t, api, srv)
+	if err := api.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("in-flight Info: %v", err)
+	}
+
+	if _, err := api.Sound("1").Info(context.Background()); !errors.Is(err, ttscrape_go.ErrShutdown) {
+		t.Errorf("Info after Shutdown returned %v, want ErrShutdown", err)
+	}
+	if n := api.SessionCount(); n != 0 {
+		t.Errorf("%d sessions left after Shutdown", n)
+	}
+}
+
+func TestShutdownCancelsRequests(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.SetFault(ttscrapetest.PathMusicDetail, ttscrapetest.Fault{Delay: 10 * time.Second})
+
+	result := infoResult(t, api, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := api.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown returned %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Shutdown took %v", elapsed)
+	}
+	if err := <-result; err == nil {
+		t.Error("in-flight Info succeeded after Shutdown gave up")
+	}
+}
+
+func TestShutdownStopsPaging(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.SetPageSize(2)
+	srv.SetFault(ttscrapetest.PathMusicItemList, ttscrapetest.Fault{Delay: 50 * time.Millisecond})
+
+	var pagingErr error
+	videos, err := api.Sound("1").Videos(context.Background(), 10, 0,
+		ttscrape_go.WithPagingError(func(err error) { pagingErr = err }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Shut down while the second page is being fetched
+	<-videos
+	if err := api.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	received := 1
+	for range videos {
+		received++
+	}
+	if !errors.Is(pagingErr, ttscrape_go.ErrShutdown) {
+		t.Errorf("paging ended with %v, want ErrShutdown", pagingErr)
+	}
+	if received >= 10 {
+		t.Errorf("received all %d videos despite Shutdown", received)
+	}
+
+	if _, err := api.Sound("1").Videos(context.Background(), 10, 0); !errors.Is(err, ttscrape_go.ErrShutdown) {
+		t.Errorf("Videos after Shutdown returned %v, want ErrShutdown", err)
+	}
+}
+
+func TestCloseCancelsRequests(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.SetFault(ttscrapetest.PathMusicDetail, ttscrapetest.Fault{Delay: 10 * time.Second})
+
+	result := infoResult(t, api, srv)
+	api.Close()
+
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Error("in-flight Info succeeded after Close")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not cancel the in-flight request")
+	}
+}
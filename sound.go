@@ -1,104 +1,112 @@
 package ttscrape_go
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"sync"
 )
 
-// Sound represents a TikTok sound/music/song
+// Sound represents a TikTok sound/music/song. Its methods may be called from
+// several goroutines at once.
 type Sound struct {
-	API      interface{} // Reference to the TikTokAPI
+	API      *TikTokAPI // Reference to the TikTokAPI
 	ID       string
 	Title    string
 	Duration int
 	Original bool
 	AsDict   map[string]interface{}
-	mu       sync.Mutex
+	mu       sync.Mutex // Guards the fields set by Info
 }
 
 // Info retrieves information about the sound
-func (s *Sound) Info(options map[string]interface{}) (map[string]interface{}, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
+func (s *Sound) Info(ctx context.Context, options ...RequestOption) (resp map[string]interface{}, err error) {
 	// Get the API reference
-	api, ok := s.API.(interface {
-		MakeRequest(string, map[string]string, map[string]string, int) (map[string]interface{}, error)
-	})
-	if !ok {
-		return nil, fmt.Errorf("invalid API reference")
+	api := s.API
+	if api == nil {
+		return nil, errInvalidAPI
 	}
 
+	opts := newRequestOptions(options)
+
+	// Trace the call, including any page fallback
+	ctx, span := api.startSpan(ctx, "Sound.Info",
+		attrSoundID.String(s.ID),
+		attrSessionIndex.Int(opts.SessionIndex),
+	)
+	defer func() { endSpan(span, err) }()
+
 	// Set up URL parameters
 	params := map[string]string{
 		"musicId": s.ID,
 	}
 
-	// Get session index
-	sessionIndex := 0
-	if val, ok := options["session_index"]; ok {
-		if idx, ok := val.(int); ok {
-			sessionIndex = idx
-		}
-	}
-
-	// Get ms_token
-	if val, ok := options["ms_token"]; ok {
-		if token, ok := val.(string); ok {
-			params["msToken"] = token
-		}
-	}
-
-	// Get headers
-	headers := map[string]string{}
-	if val, ok := options["headers"]; ok {
-		if hdrs, ok := val.(map[string]string); ok {
-			headers = hdrs
-		}
-	}
-
 	// Make the request
-	resp, err := api.MakeRequest(
-		"https://www.tiktok.com/api/music/detail/",
-		params,
-		headers,
-		sessionIndex,
-	)
+	resp, err = api.makeRequest(ctx, opts.call("/api/music/detail/", params))
+
+	// Fall back to the music page if the endpoint is blocked
+	resp, err = withPageFallback(ctx, s.API, opts, resp, err, "musicInfo",
+		"/music/sound-"+s.ID, scopeMusicDetail)
 	if err != nil {
 		return nil, err
 	}
 
 	// Check if response is valid
 	if resp == nil {
-		return nil, fmt.Errorf("TikTok returned an invalid response")
+		return nil, errInvalidResponse
 	}
 
 	// Extract data
+	s.mu.Lock()
 	s.AsDict = resp
 	s.extractFromData()
+	s.mu.Unlock()
 
 	return resp, nil
 }
 
 // Videos retrieves videos that use this sound
-func (s *Sound) Videos(count int, cursor int, options map[string]interface{}) (chan map[string]interface{}, error) {
+func (s *Sound) Videos(ctx context.Context, count int, cursor int, options ...RequestOption) (chan map[string]interface{}, error) {
 	// Get the API reference
-	api, ok := s.API.(interface {
-		MakeRequest(string, map[string]string, map[string]string, int) (map[string]interface{}, error)
-	})
-	if !ok {
-		return nil, fmt.Errorf("invalid API reference")
+	api := s.API
+	if api == nil {
+		return nil, errInvalidAPI
 	}
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive")
+	}
+
+	opts := newRequestOptions(options)
 
 	// Create a channel to send videos
 	videos := make(chan map[string]interface{}, count)
 
+	// Register the goroutine so Shutdown can stop it and wait for it
+	stop, done, err := api.beginPaging()
+	if err != nil {
+		return nil, err
+	}
+
 	// Start a goroutine to fetch videos
 	go func() {
+		defer done()
 		defer close(videos)
 
+		end := pagingEnd{Cursor: cursor, HasMore: true}
+		defer opts.pagingEnded(&end)
+
 		currentCount := 0
 		currentCursor := cursor
+		ctx, span := api.startSpan(ctx, "Sound.Videos",
+			attrSoundID.String(s.ID),
+			attrSessionIndex.Int(opts.SessionIndex),
+			attrCursor.Int(cursor),
+		)
+		defer func() {
+			api.observePaging("sound", currentCount)
+			span.SetAttributes(attrVideos.Int(currentCount))
+			span.End()
+		}()
 
 		for currentCount < count {
 			// Set up URL parameters
@@ -108,58 +116,37 @@ func (s *Sound) Videos(count int, cursor int, options map[string]interface{}) (c
 				"cursor":  fmt.Sprintf("%d", currentCursor),
 			}
 
-			// Get session index
-			sessionIndex := 0
-			if val, ok := options["session_index"]; ok {
-				if idx, ok := val.(int); ok {
-					sessionIndex = idx
-				}
-			}
-
-			// Get ms_token
-			if val, ok := options["ms_token"]; ok {
-				if token, ok := val.(string); ok {
-					params["msToken"] = token
-				}
-			}
-
-			// Get headers
-			headers := map[string]string{}
-			if val, ok := options["headers"]; ok {
-				if hdrs, ok := val.(map[string]string); ok {
-					headers = hdrs
-				}
-			}
-
-			// Make the request
-			resp, err := api.MakeRequest(
-				"https://www.tiktok.com/api/music/item_list/",
-				params,
-				headers,
-				sessionIndex,
+			// Make the request, tracing each page under the paging span
+			pageCtx, pageSpan := api.startSpan(ctx, "Sound.Videos page",
+				attrCursor.Int(currentCursor),
+				attrSessionIndex.Int(opts.SessionIndex),
 			)
-			if err != nil {
-				return
-			}
-
-			// Check if response is valid
-			if resp == nil {
+			resp, err := api.makeRequest(pageCtx, opts.call("/api/music/item_list/", params))
+			if err := pageError(resp, err); err != nil {
+				endSpan(pageSpan, err)
+				opts.pagingFailed(err)
 				return
 			}
 
 			// Extract videos
 			itemList, ok := resp["itemList"].([]interface{})
+			api.logger().Debug("page fetched", "entity", "sound", "cursor", currentCursor, "items", len(itemList))
+			hasMore, _ := resp["hasMore"].(bool)
+			pageSpan.SetAttributes(attrItemCount.Int(len(itemList)), attrHasMore.Bool(hasMore))
+			pageSpan.End()
 			if !ok {
+				end.HasMore = false
 				return
 			}
 
 			// No more videos
 			if len(itemList) == 0 {
+				end.HasMore = false
 				return
 			}
 
 			// Send videos to channel
-			for _, item := range itemList {
+			for i, item := range itemList {
 				if currentCount >= count {
 					return
 				}
@@ -169,28 +156,157 @@ func (s *Sound) Videos(count int, cursor int, options map[string]interface{}) (c
 					continue
 				}
 
-				videos <- videoMap
+				select {
+				case videos <- videoMap:
+				case <-stop:
+					opts.pagingFailed(ErrShutdown)
+					return
+				case <-ctx.Done():
+					opts.pagingFailed(ctx.Err())
+					return
+				}
 				currentCount++
+				end.Cursor = currentCursor + i + 1
 			}
 
 			// Update cursor for next page
-			hasMore, ok := resp["hasMore"].(bool)
-			if !ok || !hasMore {
+			if !hasMore {
+				end.HasMore = false
 				return
 			}
 
-			cursor, ok := resp["cursor"].(float64)
+			nextCursor, ok := cursorFromResponse(resp)
 			if !ok {
+				end.HasMore = false
 				return
 			}
 
-			currentCursor = int(cursor)
+			currentCursor = nextCursor
+			end.Cursor = nextCursor
 		}
 	}()
 
 	return videos, nil
 }
 
+// Items retrieves videos that use this sound like Videos, but decodes each page
+// as it streams in straight into typed Items instead of maps, which allocates
+// far less. WithRawItems also keeps each item's JSON in Item.Raw.
+func (s *Sound) Items(ctx context.Context, count int, cursor int, options ...RequestOption) (chan Item, error) {
+	// Get the API reference
+	api := s.API
+	if api == nil {
+		return nil, errInvalidAPI
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive")
+	}
+
+	opts := newRequestOptions(options)
+
+	// Buffer a page at a time, since items are sent by value
+	items := make(chan Item, min(count, 30))
+
+	// Register the goroutine so Shutdown can stop it and wait for it
+	stop, done, err := api.beginPaging()
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a goroutine to fetch items
+	go func() {
+		defer done()
+		defer close(items)
+
+		end := pagingEnd{Cursor: cursor, HasMore: true}
+		defer opts.pagingEnded(&end)
+
+		currentCount := 0
+		currentCursor := cursor
+		ctx, span := api.startSpan(ctx, "Sound.Items",
+			attrSoundID.String(s.ID),
+			attrSessionIndex.Int(opts.SessionIndex),
+			attrCursor.Int(cursor),
+		)
+		defer func() {
+			api.observePaging("sound", currentCount)
+			span.SetAttributes(attrVideos.Int(currentCount))
+			span.End()
+		}()
+
+		// Every page is decoded into the same items slice
+		var page ItemListPage
+		for currentCount < count {
+			// Set up URL parameters
+			params := map[string]string{
+				"musicID": s.ID,
+				"count":   fmt.Sprintf("%d", 30), // Max count per request
+				"cursor":  fmt.Sprintf("%d", currentCursor),
+			}
+
+			// Make the request, tracing each page under the paging span
+			pageCtx, pageSpan := api.startSpan(ctx, "Sound.Items page",
+				attrCursor.Int(currentCursor),
+				attrSessionIndex.Int(opts.SessionIndex),
+			)
+			err := api.request(pageCtx, opts.call("/api/music/item_list/", params), func(r io.Reader) (responseStatus, error) {
+				if err := DecodeItemList(r, &page, opts.RawItems); err != nil {
+					return responseStatus{}, err
+				}
+				return responseStatus{Code: page.StatusCode, LogID: page.LogID}, nil
+			})
+			if err == nil && page.StatusCode != 0 {
+				err = &StatusError{Code: page.StatusCode, Message: page.StatusMsg}
+			}
+			if err != nil {
+				endSpan(pageSpan, err)
+				opts.pagingFailed(err)
+				return
+			}
+
+			api.logger().Debug("page fetched", "entity", "sound", "cursor", currentCursor, "items", len(page.Items))
+			pageSpan.SetAttributes(attrItemCount.Int(len(page.Items)), attrHasMore.Bool(page.HasMore))
+			pageSpan.End()
+
+			// No more videos
+			if len(page.Items) == 0 {
+				end.HasMore = false
+				return
+			}
+
+			// Send items to channel
+			for i, item := range page.Items {
+				if currentCount >= count {
+					return
+				}
+
+				select {
+				case items <- item:
+				case <-stop:
+					opts.pagingFailed(ErrShutdown)
+					return
+				case <-ctx.Done():
+					opts.pagingFailed(ctx.Err())
+					return
+				}
+				currentCount++
+				end.Cursor = currentCursor + i + 1
+			}
+
+			// Update cursor for next page
+			if !page.HasMore {
+				end.HasMore = false
+				return
+			}
+
+			currentCursor = int(page.Cursor)
+			end.Cursor = currentCursor
+		}
+	}()
+
+	return items, nil
+}
+
 // extractFromData extracts data from the API response
 func (s *Sound) extractFromData() {
 	if s.AsDict == nil {
@@ -203,18 +319,24 @@ func (s *Sound) extractFromData() {
 		return
 	}
 
+	// Sound details live under musicInfo.music, older responses had them directly in musicInfo
+	music, ok := musicInfo["music"].(map[string]interface{})
+	if !ok {
+		music = musicInfo
+	}
+
 	// Extract title
-	if title, ok := musicInfo["title"].(string); ok {
+	if title, ok := music["title"].(string); ok {
 		s.Title = title
 	}
 
 	// Extract duration
-	if duration, ok := musicInfo["duration"].(float64); ok {
+	if duration, ok := music["duration"].(float64); ok {
 		s.Duration = int(duration)
 	}
 
 	// Extract original
-	if original, ok := musicInfo["original"].(bool); ok {
+	if original, ok := music["original"].(bool); ok {
 		s.Original = original
 	}
-} 
\ No newline at end of file
+}
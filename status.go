@@ -0,0 +1,51 @@
+package ttscrape_go
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// errInvalidResponse is returned when TikTok answers with an empty or unusable body
+var errInvalidResponse = errors.New("TikTok returned an invalid response")
+
+// StatusError is returned when TikTok answers with a non-zero statusCode, for
+// example 10218 for a sound that does not exist
+type StatusError struct {
+	Code    int    // TikTok statusCode
+	Message string // statusMsg from the response, may be empty
+}
+
+// Error describes the status
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("TikTok returned status %d", e.Code)
+	}
+	return fmt.Sprintf("TikTok returned status %d %s", e.Code, e.Message)
+}
+
+// HTTPStatusError is returned when TikTok answers with an HTTP status outside
+// 2xx, for example 429 when rate limited, whatever the body holds
+type HTTPStatusError struct {
+	StatusCode int // HTTP status code
+}
+
+// Error describes the status
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("TikTok returned HTTP %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// CheckStatus returns a *StatusError if resp carries a non-zero TikTok
+// statusCode, and nil otherwise
+func CheckStatus(resp map[string]interface{}) error {
+	statusCode, ok := resp["statusCode"].(float64)
+	if !ok || statusCode == 0 {
+		return nil
+	}
+
+	statusMsg, _ := resp["status_msg"].(string)
+	if statusMsg == "" {
+		statusMsg, _ = resp["statusMsg"].(string)
+	}
+	return &StatusError{Code: int(statusCode), Message: statusMsg}
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+)
+
+// Store keeps incremental crawl state alongside the data it describes
+var _ ttscrape_go.CrawlStateStore = (*Store)(nil)
+
+// LoadCrawlState returns the crawl state saved under key
+func (s *Store) LoadCrawlState(ctx context.Context, key string) (ttscrape_go.CrawlState, bool, error) {
+	var state ttscrape_go.CrawlState
+	var updatedAt int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT newest_create_time, newest_id, last_cursor, pending_create_time, pending_id, updated_at
+		FROM crawl_state WHERE key = ?`,
+		key).Scan(&state.NewestCreateTime, &state.NewestID, &state.LastCursor,
+		&state.PendingCreateTime, &state.PendingID, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ttscrape_go.CrawlState{}, false, nil
+	}
+	if err != nil {
+		return ttscrape_go.CrawlState{}, false, fmt.Errorf("load crawl state %s: %w", key, err)
+	}
+
+	state.UpdatedAt = time.Unix(updatedAt, 0)
+	return state, true, nil
+}
+
+// SaveCrawlState saves the crawl state under key
+func (s *Store) SaveCrawlState(ctx context.Context, key string, state ttscrape_go.CrawlState) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO crawl_state (key, newest_create_time, newest_id, last_cursor, pending_create_time, pending_id, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			newest_create_time  = excluded.newest_create_time,
+			newest_id           = excluded.newest_id,
+			last_cursor         = excluded.last_cursor,
+			pending_create_time = excluded.pending_create_time,
+			pending_id          = excluded.pending_id,
+			updated_at          = excluded.updated_at`,
+		key, state.NewestCreateTime, state.NewestID, state.LastCursor,
+		state.PendingCreateTime, state.PendingID, state.UpdatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("save crawl state %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order, each in its own transaction. The index of a
+// migration plus one is its schema version. Never edit a released migration;
+// append a new one instead.
+var migrations = []string{
+	// 1: initial schema
+	`
+	CREATE TABLE authors (
+		id              TEXT PRIMARY KEY,
+		unique_id       TEXT,
+		nickname        TEXT,
+		sec_uid         TEXT,
+		verified        INTEGER,
+		private_account INTEGER,
+		updated_at      INTEGER NOT NULL
+	);
+	CREATE INDEX authors_unique_id ON authors (unique_id);
+
+	CREATE TABLE sounds (
+		id          TEXT PRIMARY KEY,
+		title       TEXT,
+		author_name TEXT,
+		author_id   TEXT,
+		duration    INTEGER,
+		original    INTEGER,
+		cover_url   TEXT,
+		play_url    TEXT,
+		raw         TEXT,
+		updated_at  INTEGER NOT NULL
+	);
+
+	CREATE TABLE videos (
+		id          TEXT PRIMARY KEY,
+		sound_id    TEXT,
+		author_id   TEXT,
+		description TEXT,
+		create_time INTEGER,
+		duration    INTEGER,
+		raw         TEXT,
+		updated_at  INTEGER NOT NULL
+	);
+	CREATE INDEX videos_sound_id ON videos (sound_id, create_time);
+	CREATE INDEX videos_author_id ON videos (author_id);
+
+	CREATE TABLE hashtags (
+		id         TEXT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+	CREATE INDEX hashtags_name ON hashtags (name);
+
+	CREATE TABLE video_hashtags (
+		video_id   TEXT NOT NULL,
+		hashtag_id TEXT NOT NULL,
+		PRIMARY KEY (video_id, hashtag_id)
+	);
+	CREATE INDEX video_hashtags_hashtag_id ON video_hashtags (hashtag_id);
+
+	CREATE TABLE sound_stats (
+		sound_id    TEXT NOT NULL,
+		scraped_at  INTEGER NOT NULL,
+		video_count INTEGER,
+		PRIMARY KEY (sound_id, scraped_at)
+	);
+
+	CREATE TABLE video_stats (
+		video_id      TEXT NOT NULL,
+		scraped_at    INTEGER NOT NULL,
+		play_count    INTEGER,
+		like_count    INTEGER,
+		comment_count INTEGER,
+		share_count   INTEGER,
+		collect_count INTEGER,
+		PRIMARY KEY (video_id, scraped_at)
+	);
+	`,
+
+	// 2: incremental crawl state
+	`
+	CREATE TABLE crawl_state (
+		key                TEXT PRIMARY KEY,
+		newest_create_time INTEGER NOT NULL,
+		newest_id          TEXT NOT NULL,
+		last_cursor        INTEGER NOT NULL,
+		updated_at         INTEGER NOT NULL
+	);
+	`,
+
+	// 3: resumable crawls, holding the newest video sent by an unfinished
+	// crawl (CrawlState.PendingCreateTime and PendingID)
+	`
+	ALTER TABLE crawl_state ADD COLUMN pending_create_time INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE crawl_state ADD COLUMN pending_id TEXT NOT NULL DEFAULT '';
+	`,
+}
+
+// migrate brings the schema up to the latest version
+func migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d)", current, len(migrations))
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		if err := applyMigration(ctx, db, version); err != nil {
+			return fmt.Errorf("apply migration %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration runs a single migration and records it
+func applyMigration(ctx context.Context, db *sql.DB, version int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migrations[version-1]); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, strftime('%s', 'now'))`, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,297 @@
+// Package store saves scraped sounds and videos into an embedded SQLite
+// database with a normalized schema:
+//
+//   - sounds, videos, authors and hashtags keyed on their TikTok IDs
+//   - video_hashtags linking videos to hashtags
+//   - sound_stats and video_stats holding a snapshot of the counters per scrape
+//
+// Entities are upserted, so saving the same response again keeps a single row
+// per sound, video, author and hashtag and only moves its updated_at. Every save
+// also adds a stats row stamped with the save time, to the second, so repeated
+// scrapes build up a history of the counters. The schema is migrated
+// automatically by Open.
+//
+//	db, err := store.Open(ctx, "tiktok.db")
+//	defer db.Close()
+//	info, err := api.Sound(id).Info(ctx)
+//	db.SaveSound(ctx, info)
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fortindustries/ttscrape-go/export"
+
+	_ "modernc.org/sqlite" // Registers the pure Go "sqlite" driver
+)
+
+// Store is a SQLite database of scraped data. It is safe for concurrent use.
+type Store struct {
+	db  *sql.DB
+	now func() time.Time // Clock for updated_at and stat snapshots
+}
+
+// Open opens or creates the database at path and migrates it to the latest schema
+func Open(ctx context.Context, path string) (*Store, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	// SQLite allows a single writer, so serialise access instead of retrying on SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db, now: time.Now}, nil
+}
+
+// DB returns the underlying database for queries
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// SaveSound upserts a sound and its author and records a stat snapshot. info is
+// either the response returned by Sound.Info or its musicInfo object.
+func (s *Store) SaveSound(ctx context.Context, info map[string]interface{}) error {
+	info = soundInfo(info)
+
+	id := text(info, "music.id")
+	if id == "" {
+		return errors.New("store: sound has no music.id")
+	}
+
+	return s.inTx(ctx, func(tx *sql.Tx, now int64) error {
+		if err := upsertAuthor(ctx, tx, info["author"], now); err != nil {
+			return err
+		}
+
+		raw, err := json.Marshal(info)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO sounds (id, title, author_name, author_id, duration, original, cover_url, play_url, raw, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				title       = COALESCE(excluded.title, title),
+				author_name = COALESCE(excluded.author_name, author_name),
+				author_id   = COALESCE(excluded.author_id, author_id),
+				duration    = COALESCE(excluded.duration, duration),
+				original    = COALESCE(excluded.original, original),
+				cover_url   = COALESCE(excluded.cover_url, cover_url),
+				play_url    = COALESCE(excluded.play_url, play_url),
+				raw         = excluded.raw,
+				updated_at  = excluded.updated_at`,
+			id, nullText(info, "music.title"), nullText(info, "music.authorName"), nullText(info, "author.id"),
+			integer(info, "music.duration"), boolean(info, "music.original"),
+			nullText(info, "music.coverLarge"), nullText(info, "music.playUrl"), string(raw), now)
+		if err != nil {
+			return fmt.Errorf("upsert sound %s: %w", id, err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO sound_stats (sound_id, scraped_at, video_count) VALUES (?, ?, ?)`,
+			id, now, integer(info, "stats.videoCount"))
+		if err != nil {
+			return fmt.Errorf("record sound stats %s: %w", id, err)
+		}
+		return nil
+	})
+}
+
+// SaveVideos upserts videos with their authors and hashtags and records a stat
+// snapshot for each, all in one transaction. soundID is recorded as the sound of
+// videos that do not carry one.
+func (s *Store) SaveVideos(ctx context.Context, soundID string, items []map[string]interface{}) error {
+	return s.inTx(ctx, func(tx *sql.Tx, now int64) error {
+		for _, item := range items {
+			if err := saveVideo(ctx, tx, soundID, item, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveVideo upserts a single video. See SaveVideos.
+func (s *Store) SaveVideo(ctx context.Context, soundID string, item map[string]interface{}) error {
+	return s.SaveVideos(ctx, soundID, []map[string]interface{}{item})
+}
+
+// saveVideo upserts a video, its author and hashtags and records its stats
+func saveVideo(ctx context.Context, tx *sql.Tx, soundID string, item map[string]interface{}, now int64) error {
+	id := text(item, "id")
+	if id == "" {
+		return errors.New("store: video has no id")
+	}
+	if videoSound := text(item, "music.id"); videoSound != "" {
+		soundID = videoSound
+	}
+
+	if err := upsertAuthor(ctx, tx, item["author"], now); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO videos (id, sound_id, author_id, description, create_time, duration, raw, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			sound_id    = COALESCE(excluded.sound_id, sound_id),
+			author_id   = COALESCE(excluded.author_id, author_id),
+			description = COALESCE(excluded.description, description),
+			create_time = COALESCE(excluded.create_time, create_time),
+			duration    = COALESCE(excluded.duration, duration),
+			raw         = excluded.raw,
+			updated_at  = excluded.updated_at`,
+		id, nullString(soundID), nullText(item, "author.id"), nullText(item, "desc"),
+		integer(item, "createTime"), integer(item, "video.duration"), string(raw), now)
+	if err != nil {
+		return fmt.Errorf("upsert video %s: %w", id, err)
+	}
+
+	// Hashtags carry their IDs in challenges; textExtra only has names
+	challenges, _ := item["challenges"].([]interface{})
+	for _, c := range challenges {
+		challenge, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hashtagID, name := text(challenge, "id"), text(challenge, "title")
+		if hashtagID == "" || name == "" {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO hashtags (id, name, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET name = excluded.name, updated_at = excluded.updated_at`,
+			hashtagID, name, now)
+		if err != nil {
+			return fmt.Errorf("upsert hashtag %s: %w", hashtagID, err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO video_hashtags (video_id, hashtag_id) VALUES (?, ?)`,
+			id, hashtagID)
+		if err != nil {
+			return fmt.Errorf("link hashtag %s: %w", hashtagID, err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO video_stats (video_id, scraped_at, play_count, like_count, comment_count, share_count, collect_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		id, now, integer(item, "stats.playCount"), integer(item, "stats.diggCount"),
+		integer(item, "stats.commentCount"), integer(item, "stats.shareCount"), integer(item, "stats.collectCount"))
+	if err != nil {
+		return fmt.Errorf("record video stats %s: %w", id, err)
+	}
+	return nil
+}
+
+// upsertAuthor upserts an author object, ignoring values that are not authors
+func upsertAuthor(ctx context.Context, tx *sql.Tx, value interface{}, now int64) error {
+	author, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	id := text(author, "id")
+	if id == "" {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO authors (id, unique_id, nickname, sec_uid, verified, private_account, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			unique_id       = COALESCE(excluded.unique_id, unique_id),
+			nickname        = COALESCE(excluded.nickname, nickname),
+			sec_uid         = COALESCE(excluded.sec_uid, sec_uid),
+			verified        = COALESCE(excluded.verified, verified),
+			private_account = COALESCE(excluded.private_account, private_account),
+			updated_at      = excluded.updated_at`,
+		id, nullText(author, "uniqueId"), nullText(author, "nickname"), nullText(author, "secUid"),
+		boolean(author, "verified"), boolean(author, "privateAccount"), now)
+	if err != nil {
+		return fmt.Errorf("upsert author %s: %w", id, err)
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction, passing the scrape time in Unix seconds
+func (s *Store) inTx(ctx context.Context, fn func(tx *sql.Tx, now int64) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx, s.now().Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// soundInfo returns the musicInfo object of a Sound.Info response
+func soundInfo(info map[string]interface{}) map[string]interface{} {
+	if musicInfo, ok := info["musicInfo"].(map[string]interface{}); ok {
+		return musicInfo
+	}
+	return info
+}
+
+// text returns the value at path formatted as a string, or "" if missing
+func text(record map[string]interface{}, path string) string {
+	value, _ := export.Lookup(record, path)
+	return export.FormatValue(value)
+}
+
+// nullText returns the value at path as a string, or NULL if missing or empty
+func nullText(record map[string]interface{}, path string) sql.NullString {
+	return nullString(text(record, path))
+}
+
+// nullString returns s, or NULL if empty
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// integer returns the value at path as an integer, or NULL if missing. TikTok
+// sends some counters as strings, so numeric strings are accepted.
+func integer(record map[string]interface{}, path string) sql.NullInt64 {
+	value, _ := export.Lookup(record, path)
+	switch v := value.(type) {
+	case float64:
+		return sql.NullInt64{Int64: int64(v), Valid: true}
+	case string:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return sql.NullInt64{Int64: n, Valid: true}
+		}
+	}
+	return sql.NullInt64{}
+}
+
+// boolean returns the value at path as a boolean, or NULL if missing
+func boolean(record map[string]interface{}, path string) sql.NullBool {
+	value, _ := export.Lookup(record, path)
+	if b, ok := value.(bool); ok {
+		return sql.NullBool{Bool: b, Valid: true}
+	}
+	return sql.NullBool{}
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// openTest opens a database in a temporary directory with a clock the test controls
+func openTest(t *testing.T, now *time.Time) *Store {
+	t.Helper()
+	db, err := Open(context.Background(), filepath.Join(t.TempDir(), "tiktok.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.now = func() time.Time { return *now }
+	return db
+}
+
+// count returns the number of rows in table
+func count(t *testing.T, db *Store, table string) int {
+	t.Helper()
+	var n int
+	if err := db.DB().QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestSaveSoundTwice(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	db := openTest(t, &now)
+	fixture := ttscrapetest.SyntheticFixture("1", 3)
+
+	// Saving within the same second keeps a single snapshot
+	for range 2 {
+		if err := db.SaveSound(ctx, fixture.Info); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := count(t, db, "sound_stats"); n != 1 {
+		t.Errorf("got %d snapshots, want 1", n)
+	}
+
+	// A later save updates the row and adds a snapshot
+	now = now.Add(time.Hour)
+	if err := db.SaveSound(ctx, fixture.Info); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, db, "sounds"); n != 1 {
+		t.Errorf("got %d sounds, want 1", n)
+	}
+	if n := count(t, db, "sound_stats"); n != 2 {
+		t.Errorf("got %d snapshots, want 2", n)
+	}
+	var updatedAt int64
+	if err := db.DB().QueryRow("SELECT updated_at FROM sounds WHERE id = '1'").Scan(&updatedAt); err != nil {
+		t.Fatal(err)
+	}
+	if updatedAt != now.Unix() {
+		t.Errorf("updated_at is %d, want %d", updatedAt, now.Unix())
+	}
+}
+
+func TestSaveVideos(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	db := openTest(t, &now)
+	fixture := ttscrapetest.SyntheticFixture("1", 5)
+
+	if err := db.SaveVideos(ctx, "1", fixture.Videos); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	if err := db.SaveVideos(ctx, "1", fixture.Videos); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := count(t, db, "videos"); n != 5 {
+		t.Errorf("got %d videos, want 5", n)
+	}
+	if n := count(t, db, "video_stats"); n != 10 {
+		t.Errorf("got %d video snapshots, want 10", n)
+	}
+	if n := count(t, db, "authors"); n == 0 {
+		t.Error("no authors saved")
+	}
+}
+
+func TestSaveVideoWithoutID(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	db := openTest(t, &now)
+
+	err := db.SaveVideo(context.Background(), "1", map[string]interface{}{"desc": "no id"})
+	if err == nil {
+		t.Fatal("saved a video without an id")
+	}
+	if n := count(t, db, "videos"); n != 0 {
+		t.Errorf("got %d videos, want 0", n)
+	}
+}
+
+func TestCrawlState(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	db := openTest(t, &now)
+
+	if _, ok, err := db.LoadCrawlState(ctx, "sound:1"); err != nil || ok {
+		t.Fatalf("got state %v, error %v for an unknown key", ok, err)
+	}
+
+	want := ttscrape_go.CrawlState{
+		NewestCreateTime:  1700000000,
+		NewestID:          "7300000000000000001",
+		LastCursor:        20,
+		PendingCreateTime: 1700003600,
+		PendingID:         "7300000000000000002",
+		UpdatedAt:         now,
+	}
+	for range 2 {
+		if err := db.SaveCrawlState(ctx, "sound:1", want); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, ok, err := db.LoadCrawlState(ctx, "sound:1")
+	if err != nil || !ok || got != want {
+		t.Errorf("got %+v, %v, %v; want %+v", got, ok, err, want)
+	}
+}
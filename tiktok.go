@@ -1,20 +1,29 @@
 package ttscrape_go
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"math/rand"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
-	"os"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
+	"golang.org/x/time/rate"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// defaultBaseURL is where sessions send requests unless configured otherwise
+const defaultBaseURL = "https://www.tiktok.com"
+
 // TikTokSession represents a browser session for TikTok
 type TikTokSession struct {
 	Context    context.Context
@@ -25,22 +34,53 @@ type TikTokSession struct {
 	Params     map[string]string
 	BaseURL    string
 	BrowserFree bool // Flag to indicate if this session operates without a browser
+	Jar        http.CookieJar // Cookies sent with every request made by this session
+
+	transport http.RoundTripper // Sends requests through Proxy, nil to use the API's transport
+	limiter   *rate.Limiter     // Paces API requests, nil when unlimited
 }
 
-// TikTokAPI is the main API client for TikTok
+// TikTokAPI is the main API client for TikTok.
+//
+// A TikTokAPI is safe for concurrent use once configured: entity methods,
+// paging goroutines, AddSession, RemoveSession, Sessions and SetRateLimit may
+// all run at the same time. The other Set* methods and exported fields
+// configure the client and should not change while requests are in flight.
 type TikTokAPI struct {
-	Sessions []*TikTokSession
-	Logger   *log.Logger
+	Logger   *slog.Logger // Structured log output, nil to disable logging
 	Headless bool // Flag to indicate if browser should run in headless mode
 	BrowserFree bool // Flag to indicate if we should try to operate without a browser after initial setup
+	PageFallback bool // Flag to indicate if Info calls should fall back to the server-rendered page
+	HTTPClient *http.Client // Optional client whose transport, timeout and redirect policy are used for all requests
+	BaseURL    string       // Optional override for every session's BaseURL, e.g. a mirror or local stand-in server
+	Cache      *ResponseCache // Optional cache for API responses
+	Metrics    Metrics        // Optional sink for request, session and paging measurements, swapped with SetMetrics once requests run
+	MaxRetries int            // Number of times a failed request is retried, 0 to never retry
+	TracerProvider trace.TracerProvider // Optional OpenTelemetry provider, the global one is used when nil
+	Proxies    []string // Proxy URLs assigned to new sessions round-robin, empty for direct connections
+	RateLimit  float64  // Maximum API requests per second per session, 0 for no limit
+	RateBurst  int      // Requests a session may make at once before RateLimit applies
+	RequestTimeout time.Duration // Default bound on each API request with its retries, 0 for no limit
+	BrowserPath    string        // Chrome or Chromium executable for browser sessions, found automatically if empty
+
+	mu        sync.RWMutex     // Guards sessions and the rate limit
+	metricsMu sync.RWMutex     // Guards Metrics, which SetMetrics may swap while requests run
+	sessions []*TikTokSession // Session pool, read through Sessions and session
+	life     lifecycle        // Running work, drained by Shutdown
 }
 
-// NewTikTokAPI creates a new TikTok API client
+// Retry backoff bounds
+const (
+	retryBaseDelay = 250 * time.Millisecond
+	retryMaxDelay  = 5 * time.Second
+)
+
+// NewTikTokAPI creates a new TikTok API client that logs text records to stderr.
+// logLevel is a Python-style level such as LogLevelInfo; 0 logs warnings and
+// errors. Use SetLogger to log elsewhere.
 func NewTikTokAPI(logLevel int) *TikTokAPI {
-	logger := log.New(os.Stdout, "TikTokAPI: ", log.LstdFlags)
 	return &TikTokAPI{
-		Sessions: make([]*TikTokSession, 0),
-		Logger:   logger,
+		Logger:   newDefaultLogger(logLevel),
 		Headless: true, // Default to headless mode for better performance
 		BrowserFree: false, // Default to using browser for compatibility
 	}
@@ -51,57 +91,186 @@ func (api *TikTokAPI) SetHeadless(headless bool) {
 	api.Headless = headless
 }
 
+// SetBaseURL sets the base URL that every endpoint is built from, overriding
+// each session's BaseURL. Use it to route through a mirror, a recording proxy
+// or a local stand-in server.
+func (api *TikTokAPI) SetBaseURL(baseURL string) {
+	api.BaseURL = strings.TrimRight(baseURL, "/")
+}
+
+// endpointURL resolves an endpoint path such as "/api/music/detail/" against the
+// base URL used by a session. Absolute URLs are returned unchanged.
+func (api *TikTokAPI) endpointURL(session *TikTokSession, endpoint string) string {
+	if !strings.HasPrefix(endpoint, "/") {
+		return endpoint
+	}
+
+	baseURL := api.BaseURL
+	if baseURL == "" {
+		baseURL = session.BaseURL
+	}
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	return strings.TrimRight(baseURL, "/") + endpoint
+}
+
+// sessionBaseURL returns the base URL given to new sessions
+func (api *TikTokAPI) sessionBaseURL() string {
+	if api.BaseURL != "" {
+		return api.BaseURL
+	}
+	return defaultBaseURL
+}
+
+// SetHTTPClient sets the client whose transport, timeout and redirect policy are used for all requests.
+// Cookies are still kept per session.
+func (api *TikTokAPI) SetHTTPClient(client *http.Client) {
+	api.HTTPClient = client
+}
+
+// httpClient returns a client for requests that use the given cookie jar
+func (api *TikTokAPI) httpClient(jar http.CookieJar) *http.Client {
+	client := &http.Client{Jar: jar}
+	if api.HTTPClient != nil {
+		client.Transport = api.HTTPClient.Transport
+		client.Timeout = api.HTTPClient.Timeout
+		client.CheckRedirect = api.HTTPClient.CheckRedirect
+	}
+	return client
+}
+
+// sessionClient returns a client for requests made by a session, sent through
+// the session's proxy if it has one
+func (api *TikTokAPI) sessionClient(session *TikTokSession) *http.Client {
+	client := api.httpClient(session.Jar)
+	if session.transport != nil {
+		client.Transport = session.transport
+	}
+	return client
+}
+
+// SetProxies sets the proxies given to sessions created afterwards. Session i
+// uses proxies[i % len(proxies)], for browser sessions and plain HTTP alike.
+func (api *TikTokAPI) SetProxies(proxies []string) {
+	api.Proxies = proxies
+}
+
+// sessionProxy returns the proxy for the session at index
+func (api *TikTokAPI) sessionProxy(index int) string {
+	if len(api.Proxies) == 0 {
+		return ""
+	}
+	return api.Proxies[index%len(api.Proxies)]
+}
+
+// proxyTransport returns a transport that sends requests through proxy. It
+// copies the configured client's transport when that is an *http.Transport.
+func (api *TikTokAPI) proxyTransport(proxy string) (http.RoundTripper, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", proxy)
+	}
+
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if api.HTTPClient != nil && api.HTTPClient.Transport != nil {
+		base, ok = api.HTTPClient.Transport.(*http.Transport)
+	}
+	if !ok {
+		return nil, fmt.Errorf("proxies need an *http.Transport, the HTTP client uses %T", api.HTTPClient.Transport)
+	}
+
+	transport := base.Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	return transport, nil
+}
+
+// SetRequestTimeout bounds each API request, including its retries, unless the
+// call sets its own with WithTimeout. Downloads are not affected.
+func (api *TikTokAPI) SetRequestTimeout(timeout time.Duration) {
+	api.RequestTimeout = timeout
+}
+
+// SetBrowserPath sets the Chrome or Chromium executable used for browser sessions
+func (api *TikTokAPI) SetBrowserPath(path string) {
+	api.BrowserPath = path
+}
+
+// SetMaxRetries sets how many times a request is retried after a network
+// error or a 429/5xx response. Retries back off exponentially.
+func (api *TikTokAPI) SetMaxRetries(maxRetries int) {
+	api.MaxRetries = maxRetries
+}
+
 // SetBrowserFree sets whether to operate without a browser after initial setup
 func (api *TikTokAPI) SetBrowserFree(browserFree bool) {
 	api.BrowserFree = browserFree
 }
 
-// CreateSessions creates browser sessions for TikTok
+// CreateSessions creates browser sessions for TikTok and adds them to the pool.
+// ctx bounds creating the sessions; they stay open until Close or Shutdown.
 func (api *TikTokAPI) CreateSessions(ctx context.Context, numSessions int, msTokens []string, sleepAfter int, browser string) error {
+	created := 0
+
 	// If we have valid msTokens and BrowserFree is enabled, create browser-free sessions
 	if api.BrowserFree && len(msTokens) >= numSessions {
 		for i := 0; i < numSessions; i++ {
 			if msTokens[i] == "" {
 				continue // Skip empty tokens
 			}
-			
-			session := &TikTokSession{
-				MsToken:    msTokens[i],
-				Headers:    createDefaultHeaders(),
-				Params:     createDefaultParams(msTokens[i]),
-				BaseURL:    "https://www.tiktok.com",
-				BrowserFree: true,
+
+			if _, err := api.addSession(ctx, msTokens[i], 0, true); err != nil {
+				return err
 			}
-			
-			api.Sessions = append(api.Sessions, session)
+			created++
 		}
 		
 		// If we have all the sessions we need, return early
-		if len(api.Sessions) == numSessions {
+		if created == numSessions {
 			return nil
 		}
 	}
 	
 	// Otherwise, create browser sessions for the remaining slots
-	remainingSessions := numSessions - len(api.Sessions)
+	remainingSessions := numSessions - created
 	for i := 0; i < remainingSessions; i++ {
 		msToken := ""
 		if i < len(msTokens) && msTokens[i] != "" {
 			msToken = msTokens[i]
 		}
 
-		session, err := api.createSession(ctx, "https://www.tiktok.com", msToken, "", sleepAfter)
-		if err != nil {
+		if _, err := api.addSession(ctx, msToken, sleepAfter, false); err != nil {
 			return err
 		}
-
-		api.Sessions = append(api.Sessions, session)
 		time.Sleep(time.Duration(sleepAfter) * time.Second)
 	}
 
 	return nil
 }
 
+// newBrowserFreeSession creates a session that makes plain HTTP requests with msToken
+func (api *TikTokAPI) newBrowserFreeSession(msToken string, proxy string) (*TikTokSession, error) {
+	transport, err := api.proxyTransport(proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TikTokSession{
+		MsToken:    msToken,
+		Proxy:      proxy,
+		Headers:    createDefaultHeaders(),
+		Params:     createDefaultParams(msToken),
+		BaseURL:    api.sessionBaseURL(),
+		BrowserFree: true,
+		Jar:        newSessionJar(msToken),
+		transport:  transport,
+	}, nil
+}
+
 // createDefaultHeaders creates a default set of headers for browser-free sessions
 func createDefaultHeaders() map[string]string {
 	return map[string]string{
@@ -169,8 +338,26 @@ func (api *TikTokAPI) createSession(ctx context.Context, startURL string, msToke
 		opts = append(opts, chromedp.ProxyServer(proxy))
 	}
 
-	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
-	browserCtx, _ := chromedp.NewContext(allocCtx)
+	if api.BrowserPath != "" {
+		opts = append(opts, chromedp.ExecPath(api.BrowserPath))
+	}
+
+	// Plain HTTP requests made by the session use the same proxy as the browser
+	transport, err := api.proxyTransport(proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cancelling the browser context closes the tab and browser, then the allocator cleans up.
+	// The browser lives until the session is closed, ctx only bounds its setup.
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.WithoutCancel(ctx), opts...)
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	cancel := func() {
+		cancelBrowser()
+		cancelAlloc()
+	}
+	stopSetup := context.AfterFunc(ctx, cancel)
+	defer stopSetup()
 
 	session := &TikTokSession{
 		Context:    browserCtx,
@@ -179,12 +366,14 @@ func (api *TikTokAPI) createSession(ctx context.Context, startURL string, msToke
 		Proxy:      proxy,
 		Headers:    make(map[string]string),
 		Params:     make(map[string]string),
-		BaseURL:    "https://www.tiktok.com",
+		BaseURL:    startURL,
 		BrowserFree: false,
+		Jar:        newSessionJar(msToken),
+		transport:  transport,
 	}
 
 	// Navigate to TikTok
-	err := chromedp.Run(browserCtx, chromedp.Navigate(startURL))
+	err = chromedp.Run(browserCtx, chromedp.Navigate(startURL))
 	if err != nil {
 		cancel()
 		return nil, err
@@ -198,6 +387,11 @@ func (api *TikTokAPI) createSession(ctx context.Context, startURL string, msToke
 	}
 
 	time.Sleep(time.Duration(sleepAfter) * time.Second)
+
+	// Setup is over, so ctx no longer closes the browser, unless it already has
+	if !stopSetup() {
+		return nil, ctx.Err()
+	}
 	
 	// If browser-free mode is enabled and we have a valid msToken, convert this to a browser-free session
 	if api.BrowserFree && session.MsToken != "" {
@@ -205,6 +399,7 @@ func (api *TikTokAPI) createSession(ctx context.Context, startURL string, msToke
 		msToken := session.MsToken
 		headers := session.Headers
 		params := session.Params
+		jar := session.Jar
 		
 		// Close the browser
 		session.CancelFunc()
@@ -212,10 +407,13 @@ func (api *TikTokAPI) createSession(ctx context.Context, startURL string, msToke
 		// Create a browser-free session with the same data
 		return &TikTokSession{
 			MsToken:    msToken,
+			Proxy:      proxy,
 			Headers:    headers,
 			Params:     params,
-			BaseURL:    "https://www.tiktok.com",
+			BaseURL:    session.BaseURL,
 			BrowserFree: true,
+			Jar:        jar,
+			transport:  transport,
 		}, nil
 	}
 	
@@ -275,94 +473,351 @@ func (api *TikTokAPI) setSessionParams(session *TikTokSession) error {
 		session.Params["msToken"] = session.MsToken
 	}
 
+	// Copy the browser's cookies so plain HTTP requests look like the browser's
+	var cookies []*network.Cookie
+	err = chromedp.Run(session.Context, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = network.GetCookies().WithURLs([]string{session.BaseURL}).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return fmt.Errorf("read browser cookies: %w", err)
+	}
+
+	jarCookies := make([]*http.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		jarCookies = append(jarCookies, &http.Cookie{
+			Name:   c.Name,
+			Value:  c.Value,
+			Domain: c.Domain,
+			Path:   c.Path,
+		})
+	}
+	if baseURL, err := url.Parse(session.BaseURL); err == nil {
+		session.Jar.SetCookies(baseURL, jarCookies)
+	}
+
 	return nil
 }
 
-// MakeRequest makes an HTTP request to TikTok
-func (api *TikTokAPI) MakeRequest(urlStr string, params map[string]string, headers map[string]string, sessionIndex int) (map[string]interface{}, error) {
-	if sessionIndex >= len(api.Sessions) {
-		return nil, fmt.Errorf("session index out of range")
-	}
+// newSessionJar creates a cookie jar seeded with the session's msToken
+func newSessionJar(msToken string) http.CookieJar {
+	// cookiejar.New only fails for invalid options
+	jar, _ := cookiejar.New(nil)
 
-	session := api.Sessions[sessionIndex]
-	
-	// For browser-free sessions, just use HTTP client
-	if session.BrowserFree {
-		return api.makeHTTPRequest(session, urlStr, params, headers)
+	if msToken != "" {
+		baseURL, _ := url.Parse(defaultBaseURL)
+		jar.SetCookies(baseURL, []*http.Cookie{{
+			Name:   "msToken",
+			Value:  msToken,
+			Domain: ".tiktok.com",
+			Path:   "/",
+		}})
 	}
-	
-	// Merge params
-	mergedParams := make(map[string]string)
-	for k, v := range session.Params {
-		mergedParams[k] = v
+
+	return jar
+}
+
+// MakeRequest makes an HTTP request to TikTok. urlStr is either an absolute URL
+// or an endpoint path such as "/api/music/detail/", which is resolved against
+// the session's base URL.
+func (api *TikTokAPI) MakeRequest(urlStr string, params map[string]string, headers map[string]string, sessionIndex int) (map[string]interface{}, error) {
+	return api.MakeRequestContext(context.Background(), urlStr, params, headers, sessionIndex)
+}
+
+// MakeRequestContext is like MakeRequest but carries ctx, which cancels the
+// request and parents its spans
+func (api *TikTokAPI) MakeRequestContext(ctx context.Context, urlStr string, params map[string]string, headers map[string]string, sessionIndex int) (map[string]interface{}, error) {
+	return api.makeRequest(ctx, apiCall{
+		Endpoint:     urlStr,
+		Params:       params,
+		Headers:      headers,
+		SessionIndex: sessionIndex,
+	})
+}
+
+// makeRequest makes a request on behalf of an entity, serving it from the
+// response cache when possible
+func (api *TikTokAPI) makeRequest(ctx context.Context, call apiCall) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := api.request(ctx, call, func(r io.Reader) (responseStatus, error) {
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return responseStatus{}, err
+		}
+		result, err = decodeResponse(body)
+		if err != nil {
+			return responseStatus{}, err
+		}
+		return mapResponseStatus(result), nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	for k, v := range params {
-		mergedParams[k] = v
+	return result, nil
+}
+
+// responseStatus is what the request pipeline needs to know about a decoded body
+type responseStatus struct {
+	Code  int    // TikTok statusCode, 0 when missing
+	LogID string // See responseLogID
+}
+
+// responseDecoder decodes a response body as it is read from r. It is called
+// again for each retry and for bodies served from the cache.
+type responseDecoder func(r io.Reader) (responseStatus, error)
+
+// request makes a request with retries, passing the body to decode. ctx cancels
+// the request and parents its spans. TikTok error statuses are not errors here;
+// callers check the decoded status.
+func (api *TikTokAPI) request(ctx context.Context, call apiCall, decode responseDecoder) error {
+	// Register the request so Shutdown waits for it
+	done, err := api.begin()
+	if err != nil {
+		return err
 	}
+	defer done()
 
-	// Build URL with params
-	parsedURL, err := url.Parse(urlStr)
+	session, err := api.session(call.SessionIndex)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	q := parsedURL.Query()
-	for k, v := range mergedParams {
-		q.Set(k, v)
+	ctx, cancel := api.abortable(ctx)
+	defer cancel()
+
+	endpoint := call.metricEndpoint()
+
+	// The timeout covers every attempt
+	if call.Timeout == 0 {
+		call.Timeout = api.RequestTimeout
+	}
+	if call.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, call.Timeout)
+		defer cancel()
 	}
-	parsedURL.RawQuery = q.Encode()
 
-	// Create request
-	req, err := http.NewRequest("GET", parsedURL.String(), nil)
+	// Serve from the cache if possible
+	cacheKey, ttl := api.Cache.policy(call)
+	if ttl > 0 && call.CachePolicy == CacheDefault {
+		if body, ok := api.Cache.get(cacheKey); ok {
+			api.metrics().ObserveRequest(RequestMetric{Endpoint: endpoint, Outcome: OutcomeCacheHit})
+			_, err := decode(bytes.NewReader(body))
+			return err
+		}
+	}
+	cache := ttl > 0 && call.CachePolicy != CacheBypass
+
+	// Browser and browser-free sessions both send API requests over plain HTTP
+	var body []byte
+	var status responseStatus
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		body, status, retry, err = api.attemptRequest(ctx, session, call, endpoint, attempt, decode, cache)
+		if !retry || attempt >= api.MaxRetries {
+			break
+		}
+		api.metrics().ObserveRetry(endpoint)
+		delay := retryDelay(attempt)
+		api.logger().Warn("retrying request", "endpoint", endpoint, "session_index", call.SessionIndex,
+			"attempt", attempt+1, "delay", delay, "error", err)
+		if !sleepContext(ctx, delay) {
+			break
+		}
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Set headers
-	for k, v := range session.Headers {
-		req.Header.Set(k, v)
+	// Only cache successful responses
+	if cache && status.Code == 0 {
+		api.Cache.put(cacheKey, body, ttl)
 	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
+
+	return nil
+}
+
+// attemptRequest makes a single request attempt and records it in a metric and
+// a span. With keepBody set it also returns the raw body for the cache. It
+// reports whether the failure is worth retrying.
+func (api *TikTokAPI) attemptRequest(ctx context.Context, session *TikTokSession, call apiCall, endpoint string, attempt int, decode responseDecoder, keepBody bool) ([]byte, responseStatus, bool, error) {
+	ctx, span := api.startSpan(ctx, "TikTokAPI.MakeRequest",
+		attrEndpoint.String(endpoint),
+		attrSessionIndex.Int(call.SessionIndex),
+		attrAttempt.Int(attempt),
+	)
+
+	start := time.Now()
+	metric := RequestMetric{Endpoint: endpoint}
+	var spanErr error
+	var logID string
+	defer func() {
+		metric.Duration = time.Since(start)
+		api.metrics().ObserveRequest(metric)
+		if logger := api.logger(); logger.Enabled(ctx, slog.LevelDebug) {
+			attrs := []any{"endpoint", endpoint, "session_index", call.SessionIndex, "attempt", attempt,
+				"outcome", metric.Outcome, "http_status", metric.HTTPStatus,
+				"tiktok_status", metric.TikTokStatus, "duration", metric.Duration}
+			if logID != "" {
+				attrs = append(attrs, "logid", logID)
+			}
+			if spanErr != nil {
+				attrs = append(attrs, "error", spanErr)
+			}
+			logger.DebugContext(ctx, "request", attrs...)
+		}
+
+		span.SetAttributes(attrOutcome.String(metric.Outcome))
+		if metric.HTTPStatus != 0 {
+			span.SetAttributes(attrHTTPStatus.Int(metric.HTTPStatus))
+		}
+		endSpan(span, spanErr)
+	}()
+
+	if err := session.wait(ctx); err != nil {
+		metric.Outcome = OutcomeError
+		spanErr = err
+		return nil, responseStatus{}, false, err
 	}
 
-	// Make request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := api.makeHTTPRequest(ctx, session, call)
 	if err != nil {
-		return nil, err
+		metric.Outcome = OutcomeError
+		spanErr = err
+		// A cancelled context fails every further attempt too
+		return nil, responseStatus{}, ctx.Err() == nil, err
 	}
 	defer resp.Body.Close()
-
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+	metric.HTTPStatus = resp.StatusCode
+
+	// Any status outside 2xx is a failure, even with a JSON body, so it is never
+	// cached. Rate limiting and server errors are transient. Redirects are only
+	// returned when the call's redirect policy stopped at them.
+	stoppedRedirect := call.CheckRedirect != nil && resp.StatusCode >= 300 && resp.StatusCode <= 399
+	if (resp.StatusCode < 200 || resp.StatusCode > 299) && !stoppedRedirect {
+		// Read to EOF so the connection can be reused
+		io.Copy(io.Discard, resp.Body)
+		err := &HTTPStatusError{StatusCode: resp.StatusCode}
+		metric.Outcome = OutcomeHTTPError
+		spanErr = err
+		return nil, responseStatus{}, resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+	}
+
+	// Decode the body as it arrives, keeping a copy only when it will be cached
+	reader := &bodyReader{r: resp.Body}
+	var r io.Reader = reader
+	var kept bytes.Buffer
+	if keepBody {
+		r = io.TeeReader(reader, &kept)
+	}
+	result, err := decode(r)
+	if err == nil {
+		// Read to EOF so the connection can be reused
+		_, err = io.Copy(io.Discard, r)
+	}
+	if reader.err != nil {
+		metric.Outcome = OutcomeError
+		spanErr = reader.err
+		return nil, responseStatus{}, ctx.Err() == nil, reader.err
+	}
 	if err != nil {
-		return nil, err
+		// Captcha pages and other unusable bodies are not worth retrying
+		metric.Outcome = OutcomeInvalidResponse
+		spanErr = err
+		return nil, responseStatus{}, false, err
+	}
+
+	if logID = result.LogID; logID != "" {
+		span.SetAttributes(attrTikTokLogID.String(logID))
+	}
+
+	if result.Code != 0 {
+		metric.Outcome = OutcomeTikTokError
+		metric.TikTokStatus = result.Code
+		span.SetAttributes(attrTikTokStatus.Int(metric.TikTokStatus))
+		spanErr = fmt.Errorf("TikTok returned status %d", metric.TikTokStatus)
+		return kept.Bytes(), result, false, nil
+	}
+
+	metric.Outcome = OutcomeSuccess
+	return kept.Bytes(), result, false, nil
+}
+
+// bodyReader remembers the first read error other than io.EOF, so a dropped
+// connection is not mistaken for a malformed body
+type bodyReader struct {
+	r   io.Reader
+	err error
+}
+
+// Read reads from the underlying body
+func (b *bodyReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
 	}
+	return n, err
+}
 
-	// Parse JSON
+// retryDelay returns the backoff before retry number attempt+1
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay << attempt
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// sleepContext waits for d, returning false if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// decodeResponse parses a JSON response body
+func decodeResponse(body []byte) (map[string]interface{}, error) {
 	var result map[string]interface{}
-	err = json.Unmarshal(body, &result)
+	err := json.Unmarshal(body, &result)
 	if err != nil {
 		return nil, err
 	}
-
 	return result, nil
 }
 
-// makeHTTPRequest makes a direct HTTP request without using a browser
-func (api *TikTokAPI) makeHTTPRequest(session *TikTokSession, urlStr string, params map[string]string, headers map[string]string) (map[string]interface{}, error) {
+// mapResponseStatus returns the status of a response decoded into a map
+func mapResponseStatus(result map[string]interface{}) responseStatus {
+	status := responseStatus{LogID: responseLogID(result)}
+	if statusCode, ok := result["statusCode"].(float64); ok {
+		status.Code = int(statusCode)
+	}
+	return status
+}
+
+// makeHTTPRequest makes a direct HTTP request without using a browser. The
+// caller reads and closes the response body.
+func (api *TikTokAPI) makeHTTPRequest(ctx context.Context, session *TikTokSession, call apiCall) (*http.Response, error) {
 	// Merge params
 	mergedParams := make(map[string]string)
-	for k, v := range session.Params {
-		mergedParams[k] = v
+	if call.PageScope == "" {
+		api.mu.RLock()
+		for k, v := range session.Params {
+			mergedParams[k] = v
+		}
+		api.mu.RUnlock()
 	}
-	for k, v := range params {
+	for k, v := range call.Params {
 		mergedParams[k] = v
 	}
 
 	// Build URL with params
-	parsedURL, err := url.Parse(urlStr)
+	parsedURL, err := url.Parse(api.endpointURL(session, call.Endpoint))
 	if err != nil {
 		return nil, err
 	}
@@ -374,7 +829,7 @@ func (api *TikTokAPI) makeHTTPRequest(session *TikTokSession, urlStr string, par
 	parsedURL.RawQuery = q.Encode()
 
 	// Create request
-	req, err := http.NewRequest("GET", parsedURL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", parsedURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -383,42 +838,73 @@ func (api *TikTokAPI) makeHTTPRequest(session *TikTokSession, urlStr string, par
 	for k, v := range session.Headers {
 		req.Header.Set(k, v)
 	}
-	for k, v := range headers {
+	for k, v := range call.Headers {
 		req.Header.Set(k, v)
 	}
 
 	// Make request
-	client := &http.Client{}
+	client := api.sessionClient(session)
+	if call.CheckRedirect != nil {
+		client.CheckRedirect = call.CheckRedirect
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	// TikTok rotates the msToken cookie; the session's jar picks up the new one
+	// and later requests send it as a parameter too, unless they override it
+	if _, overridden := call.Params["msToken"]; !overridden {
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == "msToken" && cookie.Value != "" && api.setMsToken(session, cookie.Value) {
+				api.logger().Debug("ms token refreshed", "session_index", call.SessionIndex, "endpoint", call.metricEndpoint())
+			}
+		}
 	}
 
-	// Parse JSON
-	var result map[string]interface{}
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return nil, err
-	}
+	return resp, nil
+}
 
-	return result, nil
+// setMsToken stores a rotated msToken on the session, reporting whether it changed
+func (api *TikTokAPI) setMsToken(session *TikTokSession, msToken string) bool {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if session.MsToken == msToken {
+		return false
+	}
+	session.MsToken = msToken
+	if session.Params != nil {
+		session.Params["msToken"] = msToken
+	}
+	return true
 }
 
-// Close closes all sessions
+// Close shuts the API down immediately: in-flight requests are cancelled,
+// paging goroutines stop and every session is closed. Use Shutdown to let
+// in-flight requests finish first.
 func (api *TikTokAPI) Close() {
-	for _, session := range api.Sessions {
-		if !session.BrowserFree && session.CancelFunc != nil {
-			session.CancelFunc()
+	api.stopAccepting()
+	api.lifecycle().abort()
+	api.closeSessions()
+}
+
+// closeSessions closes all sessions and removes them from the pool
+func (api *TikTokAPI) closeSessions() {
+	api.mu.Lock()
+	sessions := api.sessions
+	api.sessions = nil
+	api.metrics().SetActiveSessions(0)
+	api.mu.Unlock()
+
+	closed := 0
+	for _, session := range sessions {
+		if session != nil {
+			session.close()
+			closed++
 		}
 	}
-	api.Sessions = nil
+	api.logger().Debug("sessions closed", "sessions", closed)
 }
 
 // Sound returns a new Sound object
@@ -427,4 +913,46 @@ func (api *TikTokAPI) Sound(id string) *Sound {
 		API: api,
 		ID:  id,
 	}
-} 
\ No newline at end of file
+}
+
+// Video returns a new Video object
+func (api *TikTokAPI) Video(id string) *Video {
+	return &Video{
+		API: api,
+		ID:  id,
+	}
+}
+
+// VideoFromDict returns a Video populated from an item struct, such as those sent by Sound.Videos
+func (api *TikTokAPI) VideoFromDict(data map[string]interface{}) *Video {
+	video := &Video{
+		API:    api,
+		AsDict: data,
+	}
+	video.extractFromData()
+	return video
+}
+
+// User returns a new User object for the given username
+func (api *TikTokAPI) User(username string) *User {
+	return &User{
+		API:      api,
+		Username: username,
+	}
+}
+
+// Playlist returns a new Playlist object
+func (api *TikTokAPI) Playlist(id string) *Playlist {
+	return &Playlist{
+		API: api,
+		ID:  id,
+	}
+}
+
+// Hashtag returns a new Hashtag object for the given hashtag name
+func (api *TikTokAPI) Hashtag(name string) *Hashtag {
+	return &Hashtag{
+		API:  api,
+		Name: name,
+	}
+}
@@ -0,0 +1,260 @@
+package ttscrape_go_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// newStandin starts a stand-in serving sound 1 and returns an API using it
+func newStandin(t *testing.T) (*ttscrape_go.TikTokAPI, *ttscrapetest.Server) {
+	t.Helper()
+	srv := ttscrapetest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddSound("1", ttscrapetest.SyntheticFixture("1", 10))
+
+	api, err := srv.NewAPI(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { api.Close() })
+	return api, srv
+}
+
+func TestRetryServerError(t *testing.T) {
+	api, srv := newStandin(t)
+	api.SetMaxRetries(1)
+
+	srv.SetFault(ttscrapetest.PathMusicDetail, ttscrapetest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 1})
+	if _, err := api.Sound("1").Info(context.Background()); err != nil {
+		t.Fatalf("Info after a 503: %v", err)
+	}
+	if n := srv.Requests(ttscrapetest.PathMusicDetail); n != 2 {
+		t.Errorf("got %d requests, want 2", n)
+	}
+}
+
+func TestNoRetryCaptcha(t *testing.T) {
+	api, srv := newStandin(t)
+	api.SetMaxRetries(2)
+
+	srv.SetFault(ttscrapetest.PathMusicDetail, ttscrapetest.Fault{Captcha: true})
+	if _, err := api.Sound("1").Info(context.Background()); err == nil {
+		t.Fatal("Info succeeded with a captcha page")
+	}
+	if n := srv.Requests(ttscrapetest.PathMusicDetail); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestHTTPErrorNotCached(t *testing.T) {
+	// TikTok answers the first request with a 429 carrying a JSON body
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+		fmt.Fprint(w, `{"musicInfo": {"music": {"id": "1", "title": "sound"}}}`)
+	}))
+	defer srv.Close()
+
+	api := ttscrape_go.NewTikTokAPI(0)
+	api.SetBrowserFree(true)
+	api.SetBaseURL(srv.URL)
+	if err := api.CreateSessions(context.Background(), 1, []string{"token"}, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+	cache, err := ttscrape_go.NewResponseCache(ttscrape_go.CacheOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.SetCache(cache)
+
+	_, err = api.Sound("1").Info(context.Background())
+	var httpErr *ttscrape_go.HTTPStatusError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("got error %v, want HTTP 429", err)
+	}
+	if stores := cache.Stats().Stores; stores != 0 {
+		t.Fatalf("the 429 response was cached")
+	}
+
+	// The next request reaches TikTok, and its response is cached
+	for range 2 {
+		if _, err := api.Sound("1").Info(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("got %d requests, want 2", n)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	api, _ := newStandin(t)
+	api.SetRateLimit(20, 1)
+
+	// Five requests at 20 per second with no burst take at least 200ms
+	start := time.Now()
+	for range 5 {
+		if _, err := api.Sound("1").Info(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("5 requests took %v, want at least 200ms", elapsed)
+	}
+
+	// A request that would wait past its deadline for the limiter fails
+	api.SetRateLimit(0.1, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := api.Sound("1").Info(ctx); err == nil {
+		t.Error("Info succeeded while rate limited")
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.SetFault(ttscrapetest.PathMusicDetail, ttscrapetest.Fault{Delay: 5 * time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := api.Sound("1").Info(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the request ran for %v after its context expired", elapsed)
+	}
+}
+
+func TestPagingContextCancel(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.SetPageSize(2)
+	srv.SetFault(ttscrapetest.PathMusicItemList, ttscrapetest.Fault{Delay: 50 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var pagingErr error
+	videos, err := api.Sound("1").Videos(ctx, 10, 0,
+		ttscrape_go.WithPagingError(func(err error) { pagingErr = err }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	<-videos
+	cancel()
+	received := 1
+	for range videos {
+		received++
+	}
+	if !errors.Is(pagingErr, context.Canceled) {
+		t.Errorf("paging ended with %v, want context.Canceled", pagingErr)
+	}
+	if received >= 10 {
+		t.Errorf("received all %d videos after cancelling", received)
+	}
+}
+
+func TestMsTokenRotation(t *testing.T) {
+	// TikTok sets a new msToken cookie on every response
+	var (
+		requests atomic.Int32
+		sent     = make(chan string, 4)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent <- r.URL.Query().Get("msToken")
+		http.SetCookie(w, &http.Cookie{Name: "msToken", Value: fmt.Sprintf("rotated-%d", requests.Add(1))})
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"statusCode": 0, "musicInfo": {"music": {"id": "1"}}}`)
+	}))
+	defer srv.Close()
+
+	api := ttscrape_go.NewTikTokAPI(0)
+	api.SetBrowserFree(true)
+	api.SetBaseURL(srv.URL)
+	if err := api.CreateSessions(context.Background(), 1, []string{"token"}, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	defer api.Close()
+
+	ctx := context.Background()
+	for range 2 {
+		if _, err := api.Sound("1").Info(ctx, ttscrape_go.WithCachePolicy(ttscrape_go.CacheBypass)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A per-call token is sent as given and does not replace the session's
+	if _, err := api.Sound("1").Info(ctx, ttscrape_go.WithMsToken("override")); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []string{"token", "rotated-1", "override"} {
+		if got := <-sent; got != want {
+			t.Errorf("request %d sent msToken %q, want %q", i+1, got, want)
+		}
+	}
+	if token := api.Sessions()[0].MsToken; token != "rotated-2" {
+		t.Errorf("session msToken = %q, want rotated-2", token)
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	api, srv := newStandin(t)
+	srv.AddSound("2", ttscrapetest.SyntheticFixture("2", 45))
+	srv.SetPageSize(10)
+	ctx := context.Background()
+
+	// A listing cut short mid-page resumes at its first unsent video, so two
+	// listings see every video exactly once
+	seen := map[string]int{}
+	cursor, hasMore := 0, true
+	for _, count := range []int{25, 100} {
+		videos, err := api.Sound("2").Videos(ctx, count, cursor,
+			ttscrape_go.WithNextCursor(func(c int, more bool) { cursor, hasMore = c, more }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for video := range videos {
+			seen[video["id"].(string)]++
+		}
+		if count == 25 && (cursor != 25 || !hasMore) {
+			t.Errorf("after 25 videos: cursor %d, hasMore %v, want 25, true", cursor, hasMore)
+		}
+	}
+	if cursor != 45 || hasMore {
+		t.Errorf("at the end: cursor %d, hasMore %v, want 45, false", cursor, hasMore)
+	}
+	if len(seen) != 45 {
+		t.Errorf("saw %d distinct videos, want 45", len(seen))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("video %s sent %d times", id, n)
+		}
+	}
+
+	// Items reports the same cursor
+	items, err := api.Sound("2").Items(ctx, 15, 0,
+		ttscrape_go.WithNextCursor(func(c int, more bool) { cursor, hasMore = c, more }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range items {
+	}
+	if cursor != 15 || !hasMore {
+		t.Errorf("Items after 15 videos: cursor %d, hasMore %v, want 15, true", cursor, hasMore)
+	}
+}
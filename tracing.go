@@ -0,0 +1,79 @@
+package ttscrape_go
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans created by this package
+const tracerName = "github.com/fortindustries/ttscrape-go"
+
+// Span attribute keys
+const (
+	attrEndpoint     = attribute.Key("ttscrape.endpoint")
+	attrSessionIndex = attribute.Key("ttscrape.session_index")
+	attrAttempt      = attribute.Key("ttscrape.attempt")
+	attrOutcome      = attribute.Key("ttscrape.outcome")
+	attrSoundID      = attribute.Key("ttscrape.sound_id")
+	attrCursor       = attribute.Key("ttscrape.cursor")
+	attrItemCount    = attribute.Key("ttscrape.item_count")
+	attrHasMore      = attribute.Key("ttscrape.has_more")
+	attrVideos       = attribute.Key("ttscrape.videos")
+	attrTikTokStatus = attribute.Key("ttscrape.tiktok.status_code")
+	attrTikTokLogID  = attribute.Key("ttscrape.tiktok.logid")
+	attrHTTPStatus   = attribute.Key("http.response.status_code")
+)
+
+// SetTracerProvider sets the OpenTelemetry provider spans are created with. By
+// default the global provider is used, which records nothing until the
+// application installs one with otel.SetTracerProvider.
+func (api *TikTokAPI) SetTracerProvider(provider trace.TracerProvider) {
+	api.TracerProvider = provider
+}
+
+// tracer returns the tracer of the configured or global provider
+func (api *TikTokAPI) tracer() trace.Tracer {
+	provider := api.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+// startSpan starts a span as a child of any span in ctx. A nil ctx is treated
+// as context.Background so callers that passed no context still get spans.
+func (api *TikTokAPI) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return api.tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err on the span, if any, and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// responseLogID returns the logid TikTok attaches to API responses, which
+// identifies the request when reporting problems to TikTok
+func responseLogID(result map[string]interface{}) string {
+	if extra, ok := result["extra"].(map[string]interface{}); ok {
+		if logID, ok := extra["logid"].(string); ok && logID != "" {
+			return logID
+		}
+	}
+	if logPB, ok := result["log_pb"].(map[string]interface{}); ok {
+		if imprID, ok := logPB["impr_id"].(string); ok {
+			return imprID
+		}
+	}
+	return ""
+}
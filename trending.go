@@ -0,0 +1,103 @@
+package ttscrape_go
+
+import (
+	"context"
+	"fmt"
+)
+
+// maxStaleTrendingPages is how many pages in a row may bring no new videos
+// before Trending gives up, since the feed often repeats itself
+const maxStaleTrendingPages = 3
+
+// Trending retrieves videos from the For You (recommended) feed
+func (api *TikTokAPI) Trending(ctx context.Context, count int, options ...RequestOption) (chan *Video, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive")
+	}
+
+	opts := newRequestOptions(options)
+
+	// Create a channel to send videos
+	videos := make(chan *Video, count)
+
+	// Register the goroutine so Shutdown can stop it and wait for it
+	stop, done, err := api.beginPaging()
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a goroutine to fetch videos
+	go func() {
+		defer done()
+		defer close(videos)
+
+		// The feed has no cursor, so remember what was already sent
+		seen := make(map[string]bool, count)
+		defer func() {
+			api.observePaging("trending", len(seen))
+		}()
+
+		stale := 0
+		for len(seen) < count {
+			// Set up URL parameters
+			params := map[string]string{
+				"from_page": "fyp",
+				"count":     fmt.Sprintf("%d", 30), // Max count per request
+			}
+
+			// Make the request
+			resp, err := api.makeRequest(ctx, opts.call("/api/recommend/item_list/", params))
+			if err := pageError(resp, err); err != nil {
+				opts.pagingFailed(err)
+				return
+			}
+
+			// Extract videos, a page may be empty while the feed still has more
+			itemList, _ := resp["itemList"].([]interface{})
+			api.logger().Debug("page fetched", "entity", "trending", "items", len(itemList))
+
+			// Send videos to channel
+			sent := 0
+			for _, item := range itemList {
+				if len(seen) >= count {
+					return
+				}
+
+				videoMap, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				video := api.VideoFromDict(videoMap)
+				if video.ID == "" || seen[video.ID] {
+					continue
+				}
+				seen[video.ID] = true
+
+				select {
+				case videos <- video:
+				case <-stop:
+					opts.pagingFailed(ErrShutdown)
+					return
+				case <-ctx.Done():
+					opts.pagingFailed(ctx.Err())
+					return
+				}
+				sent++
+			}
+
+			// Stop when the feed has nothing more, or keeps repeating itself
+			hasMore, ok := resp["hasMore"].(bool)
+			if !ok || !hasMore {
+				return
+			}
+			if sent > 0 {
+				stale = 0
+			} else if stale++; stale >= maxStaleTrendingPages {
+				return
+			}
+		}
+	}()
+
+	return videos, nil
+}
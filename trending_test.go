@@ -0,0 +1,76 @@
+package ttscrape_go_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// trendingIDs lists count videos from the trending feed and returns their IDs
+func trendingIDs(t *testing.T, count int, feed ...[]map[string]interface{}) ([]string, *ttscrapetest.Server) {
+	t.Helper()
+	api, srv := newStandin(t)
+	srv.SetPageSize(5)
+	var items []map[string]interface{}
+	for _, page := range feed {
+		items = append(items, page...)
+	}
+	srv.SetTrending(items)
+
+	videos, err := api.Trending(context.Background(), count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for video := range videos {
+		ids = append(ids, video.ID)
+	}
+	return ids, srv
+}
+
+func TestTrending(t *testing.T) {
+	all := ttscrapetest.SyntheticFixture("1", 15).Videos
+	first, second := all[:5], all[5:10]
+
+	// A repeated page is skipped and paging carries on while there is more
+	ids, srv := trendingIDs(t, 10, first, first, second)
+	if len(ids) != 10 {
+		t.Fatalf("got %d videos, want 10", len(ids))
+	}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("video %s sent twice", id)
+		}
+		seen[id] = true
+	}
+	if n := srv.Requests(ttscrapetest.PathTrending); n != 3 {
+		t.Errorf("got %d feed requests, want 3", n)
+	}
+
+	// Paging ends when the feed has nothing more
+	if ids, _ := trendingIDs(t, 100, all); len(ids) != 15 {
+		t.Errorf("got %d videos from a 15 video feed", len(ids))
+	}
+}
+
+func TestTrendingStale(t *testing.T) {
+	all := ttscrapetest.SyntheticFixture("1", 10).Videos
+	first, second := all[:5], all[5:]
+
+	// Paging gives up after three pages in a row without new videos, even
+	// though the feed still has more
+	ids, srv := trendingIDs(t, 10, first, first, first, first, first, second)
+	if len(ids) != 5 {
+		t.Errorf("got %d videos, want the 5 before the feed went stale", len(ids))
+	}
+	if n := srv.Requests(ttscrapetest.PathTrending); n != 4 {
+		t.Errorf("got %d feed requests, want 4", n)
+	}
+
+	// Fewer stale pages than that are waited out
+	if ids, _ := trendingIDs(t, 10, first, first, first, second); len(ids) != 10 {
+		t.Errorf("got %d videos after two stale pages, want 10", len(ids))
+	}
+}
@@ -0,0 +1,202 @@
+package ttscrapetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// resultsFile is the layout of results.json written by the concurrent example
+type resultsFile struct {
+	Results map[string]struct {
+		Info   map[string]interface{}   `json:"info"`
+		Videos []map[string]interface{} `json:"videos"`
+		Error  string                   `json:"error"`
+	} `json:"results"`
+}
+
+// ReadResultsFile reads a results.json file and returns a fixture for each
+// sound that was scraped successfully, keyed by sound ID
+func ReadResultsFile(path string) (map[string]Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read results file: %w", err)
+	}
+
+	var results resultsFile
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("decode results file: %w", err)
+	}
+
+	fixtures := make(map[string]Fixture, len(results.Results))
+	for id, result := range results.Results {
+		if result.Error != "" || result.Info == nil {
+			continue
+		}
+		fixtures[id] = Fixture{
+			Info:   result.Info,
+			Videos: result.Videos,
+		}
+	}
+
+	return fixtures, nil
+}
+
+// SyntheticFixture returns a fixture for sound id with the given number of
+// generated videos. The items follow the layout of real item_list entries,
+// with author, music, stats and video blocks, so they are a realistic size
+// for benchmarks and load tests.
+func SyntheticFixture(id string, videos int) Fixture {
+	music := map[string]interface{}{
+		"id":          id,
+		"title":       "original sound - bench" + id,
+		"authorName":  "bench" + id,
+		"album":       "",
+		"duration":    float64(30),
+		"original":    true,
+		"playUrl":     "https://sf16-ies-music-va.tiktokcdn.com/obj/musically-maliva-obj/" + id + ".mp3",
+		"coverLarge":  "https://p16-sign-va.tiktokcdn.com/musically-maliva-obj/" + id + "~c5_1080x1080.jpeg",
+		"coverMedium": "https://p16-sign-va.tiktokcdn.com/musically-maliva-obj/" + id + "~c5_720x720.jpeg",
+		"coverThumb":  "https://p16-sign-va.tiktokcdn.com/musically-maliva-obj/" + id + "~c5_100x100.jpeg",
+	}
+
+	fixture := Fixture{
+		Info: map[string]interface{}{
+			"statusCode": 0,
+			"musicInfo": map[string]interface{}{
+				"music": music,
+				"author": map[string]interface{}{
+					"id":       "6800000000000000000",
+					"uniqueId": "bench" + id,
+					"nickname": "Bench " + id,
+				},
+				"stats": map[string]interface{}{
+					"videoCount": videos,
+				},
+			},
+			"shareMeta": map[string]interface{}{
+				"title": music["title"],
+				"desc":  fmt.Sprintf("%d videos", videos),
+			},
+		},
+		Videos: make([]map[string]interface{}, videos),
+	}
+
+	for i := range fixture.Videos {
+		fixture.Videos[i] = syntheticItem(id, music, i)
+	}
+	return fixture
+}
+
+// syntheticItem returns the i-th generated item_list entry for a sound
+func syntheticItem(soundID string, music map[string]interface{}, i int) map[string]interface{} {
+	videoID := fmt.Sprintf("73%017d", i)
+	authorID := fmt.Sprintf("68%017d", i%97)
+	uniqueID := fmt.Sprintf("creator%d", i%97)
+	cdn := "https://v16-webapp-prime.tiktok.com/video/tos/useast2a/tos-useast2a-pve-0068/" + videoID + "/"
+
+	return map[string]interface{}{
+		"id":         videoID,
+		"desc":       fmt.Sprintf("Video %d using sound %s #fyp #bench%d", i, soundID, i%10),
+		"createTime": float64(1700000000 + i*3600),
+		"author": map[string]interface{}{
+			"id":             authorID,
+			"uniqueId":       uniqueID,
+			"nickname":       "Creator " + uniqueID,
+			"signature":      "Generated creator profile used by ttscrapetest",
+			"verified":       i%13 == 0,
+			"secUid":         "MS4wLjABAAAA" + authorID + uniqueID,
+			"avatarThumb":    "https://p16-sign-va.tiktokcdn.com/tos-maliva-avt-0068/" + authorID + "~c5_100x100.jpeg",
+			"avatarMedium":   "https://p16-sign-va.tiktokcdn.com/tos-maliva-avt-0068/" + authorID + "~c5_720x720.jpeg",
+			"avatarLarger":   "https://p16-sign-va.tiktokcdn.com/tos-maliva-avt-0068/" + authorID + "~c5_1080x1080.jpeg",
+			"privateAccount": false,
+		},
+		"authorStats": map[string]interface{}{
+			"followerCount":  float64(1000 + i*7),
+			"followingCount": float64(100 + i%50),
+			"heartCount":     float64(50000 + i*11),
+			"videoCount":     float64(20 + i%30),
+		},
+		"music": music,
+		"stats": map[string]interface{}{
+			"diggCount":    float64(1000 + i*31),
+			"shareCount":   float64(10 + i*3),
+			"commentCount": float64(50 + i*5),
+			"playCount":    float64(10000 + i*97),
+			"collectCount": float64(20 + i),
+		},
+		"video": map[string]interface{}{
+			"id":           videoID,
+			"height":       float64(1024),
+			"width":        float64(576),
+			"duration":     float64(15 + i%45),
+			"ratio":        "720p",
+			"format":       "mp4",
+			"cover":        cdn + "cover.jpeg",
+			"originCover":  cdn + "origin.jpeg",
+			"dynamicCover": cdn + "dynamic.webp",
+			"playAddr":     cdn + "play.mp4?a=1988&br=1840&bt=920&cd=0%7C0%7C1%7C0&ch=0&cr=0&cs=0",
+			"downloadAddr": cdn + "download.mp4?a=1988&br=2044&bt=1022&cd=0%7C0%7C0%7C0&ch=0&cr=0&cs=0",
+			"bitrate":      float64(1884000),
+			"bitrateInfo": []interface{}{
+				map[string]interface{}{"Bitrate": float64(1884000), "QualityType": float64(10), "GearName": "normal_720_0", "CodecType": "h264"},
+				map[string]interface{}{"Bitrate": float64(942000), "QualityType": float64(20), "GearName": "normal_540_0", "CodecType": "h264"},
+			},
+		},
+		"challenges": []interface{}{
+			map[string]interface{}{"id": "229207", "title": "fyp", "desc": ""},
+			map[string]interface{}{"id": fmt.Sprintf("1600%d", i%10), "title": fmt.Sprintf("bench%d", i%10), "desc": ""},
+		},
+		"textExtra": []interface{}{
+			map[string]interface{}{"hashtagName": "fyp", "hashtagId": "229207", "start": float64(0), "end": float64(4), "type": float64(1)},
+			map[string]interface{}{"hashtagName": fmt.Sprintf("bench%d", i%10), "start": float64(5), "end": float64(12), "type": float64(1)},
+		},
+		"duetEnabled":   true,
+		"stitchEnabled": true,
+		"isAd":          false,
+	}
+}
+
+// SyntheticPlaylist returns a fixture for playlist (mix) id with the given
+// number of generated videos, served by mix/detail and mix/item_list
+func SyntheticPlaylist(id string, videos int) Fixture {
+	return Fixture{
+		Info: map[string]interface{}{
+			"statusCode": 0,
+			"mixInfo": map[string]interface{}{
+				"id":         id,
+				"name":       "Playlist " + id,
+				"videoCount": float64(videos),
+			},
+		},
+		Videos: SyntheticFixture(id, videos).Videos,
+	}
+}
+
+// SyntheticUser returns a fixture for username with the given number of
+// generated playlists, served by user/detail and user/playlist
+func SyntheticUser(username string, playlists int) UserFixture {
+	fixture := UserFixture{
+		Info: map[string]interface{}{
+			"statusCode": 0,
+			"userInfo": map[string]interface{}{
+				"user": map[string]interface{}{
+					"id":       "6900000000000000000",
+					"uniqueId": username,
+					"secUid":   "MS4wLjABAAAA" + username,
+					"nickname": "User " + username,
+				},
+			},
+		},
+		Playlists: make([]map[string]interface{}, playlists),
+	}
+
+	for i := range fixture.Playlists {
+		fixture.Playlists[i] = map[string]interface{}{
+			"id":         fmt.Sprintf("72%017d", i),
+			"name":       fmt.Sprintf("Playlist %d by %s", i, username),
+			"videoCount": float64(10 + i),
+		}
+	}
+	return fixture
+}
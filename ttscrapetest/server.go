@@ -0,0 +1,547 @@
+// Package ttscrapetest provides a local stand-in for TikTok's web API so code
+// built on ttscrape_go can be exercised offline.
+//
+// A Server emulates music/detail and music/item_list (with cursor paging and
+// hasMore), the server-rendered music page used for page fallback, mix/detail
+// and mix/item_list for playlists, user/detail and user/playlist, the
+// recommend/item_list trending feed and short links, and can inject error
+// status codes, captcha pages and slow responses.
+package ttscrapetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+)
+
+// Endpoint paths served by the Server
+const (
+	PathMusicDetail   = "/api/music/detail/"
+	PathMusicItemList = "/api/music/item_list/"
+	PathMixDetail     = "/api/mix/detail/"
+	PathMixItemList   = "/api/mix/item_list/"
+	PathUserDetail    = "/api/user/detail/"
+	PathUserPlaylist  = "/api/user/playlist/"
+	PathTrending      = "/api/recommend/item_list/"
+	PathShortLink     = "/t/"
+)
+
+// StatusNotFound is the TikTok statusCode returned for unknown sounds
+const StatusNotFound = 10218
+
+// DefaultPageSize is the number of items returned per item_list page unless
+// the request asks for fewer
+const DefaultPageSize = 30
+
+// Fixture holds the data served for a single sound or playlist
+type Fixture struct {
+	Info   map[string]interface{}   // music/detail or mix/detail response
+	Videos []map[string]interface{} // Item structs served by music/item_list or mix/item_list
+}
+
+// UserFixture holds the data served for a single user
+type UserFixture struct {
+	Info      map[string]interface{}   // user/detail response
+	Playlists []map[string]interface{} // Mix objects served by user/playlist
+}
+
+// Fault describes a failure injected into responses for a path
+type Fault struct {
+	StatusCode int           // HTTP status code to return instead of the normal response
+	Captcha    bool          // Serve a captcha HTML page instead of JSON
+	Empty      bool          // Serve an empty 200 body, as TikTok does for blocked requests
+	Delay      time.Duration // Wait before responding
+	Times      int           // Number of requests to affect, 0 means every request
+}
+
+// Server is a local TikTok stand-in backed by an httptest.Server
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	sounds    map[string]Fixture
+	playlists map[string]Fixture
+	users     map[string]UserFixture
+	trending  []map[string]interface{}
+	feedPos   int // Position of the next trending page
+	links     map[string]string
+	faults    map[string]*Fault
+	requests  map[string]int
+	pageSize  int
+}
+
+// NewServer starts a Server with no fixtures. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		sounds:    make(map[string]Fixture),
+		playlists: make(map[string]Fixture),
+		users:     make(map[string]UserFixture),
+		links:     make(map[string]string),
+		faults:    make(map[string]*Fault),
+		requests:  make(map[string]int),
+		pageSize:  DefaultPageSize,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathMusicDetail, s.handleMusicDetail)
+	mux.HandleFunc(PathMusicItemList, s.handleMusicItemList)
+	mux.HandleFunc("/music/", s.handleMusicPage)
+	mux.HandleFunc(PathMixDetail, s.handleMixDetail)
+	mux.HandleFunc(PathMixItemList, s.handleMixItemList)
+	mux.HandleFunc(PathUserDetail, s.handleUserDetail)
+	mux.HandleFunc(PathUserPlaylist, s.handleUserPlaylist)
+	mux.HandleFunc(PathTrending, s.handleTrending)
+	mux.HandleFunc(PathShortLink, s.handleShortLink)
+
+	s.Server = httptest.NewServer(s.count(s.injectFaults(mux)))
+	return s
+}
+
+// AddSound registers the fixture served for a sound ID
+func (s *Server) AddSound(id string, fixture Fixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sounds[id] = fixture
+}
+
+// AddPlaylist registers the fixture served for a playlist (mix) ID
+func (s *Server) AddPlaylist(id string, fixture Fixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.playlists[id] = fixture
+}
+
+// AddUser registers the fixture served for a username. user/detail finds it by
+// username or by the secUid in its Info.
+func (s *Server) AddUser(username string, fixture UserFixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[username] = fixture
+}
+
+// SetTrending sets the items of the trending feed. The feed has no cursor:
+// each request serves the next page, so repeating items in videos makes the
+// feed repeat itself the way TikTok's does. Once every item was served, the
+// feed returns an empty page without hasMore.
+func (s *Server) SetTrending(videos []map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trending = videos
+	s.feedPos = 0
+}
+
+// AddShortLink registers a short link, served at PathShortLink+code, that
+// redirects to target. Reach it through Transport with a tiktok.com URL such
+// as https://www.tiktok.com/t/<code>/.
+func (s *Server) AddShortLink(code string, target string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.links[code] = target
+}
+
+// LoadResultsFile registers every successful sound in a results file written
+// by the concurrent example (cmd/examples/results.json) and returns their IDs
+func (s *Server) LoadResultsFile(path string) ([]string, error) {
+	fixtures, err := ReadResultsFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(fixtures))
+	for id, fixture := range fixtures {
+		s.AddSound(id, fixture)
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// SetPageSize sets the maximum number of items returned per item_list page
+func (s *Server) SetPageSize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pageSize = size
+}
+
+// SetFault injects a fault into responses for path (e.g. PathMusicDetail).
+// Use "*" to affect every path.
+func (s *Server) SetFault(path string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults[path] = &fault
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = make(map[string]*Fault)
+}
+
+// Requests returns the number of requests received for path
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[path]
+}
+
+// Transport returns a RoundTripper that sends every request, whatever its
+// host, to this server. Use it with TikTokAPI.SetHTTPClient when absolute
+// tiktok.com URLs (rather than endpoint paths) must also reach the server.
+func (s *Server) Transport() http.RoundTripper {
+	target, _ := url.Parse(s.URL)
+	return &rewriteTransport{target: target, base: http.DefaultTransport}
+}
+
+// NewAPI returns a browser-free TikTokAPI with numSessions sessions whose
+// endpoint requests are served by this server
+func (s *Server) NewAPI(numSessions int) (*ttscrape_go.TikTokAPI, error) {
+	api := ttscrape_go.NewTikTokAPI(0)
+	api.SetBrowserFree(true)
+	api.SetBaseURL(s.URL)
+
+	tokens := make([]string, numSessions)
+	for i := range tokens {
+		tokens[i] = fmt.Sprintf("ttscrapetest-token-%d", i)
+	}
+
+	err := api.CreateSessions(context.Background(), numSessions, tokens, 0, "")
+	if err != nil {
+		return nil, fmt.Errorf("create sessions: %w", err)
+	}
+
+	return api, nil
+}
+
+// rewriteTransport redirects requests to a fixed scheme and host
+type rewriteTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+// RoundTrip sends the request to the target host
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	req.Host = t.target.Host
+	return t.base.RoundTrip(req)
+}
+
+// count records each request by path
+func (s *Server) count(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		s.mu.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// injectFaults applies the fault registered for the request's path, if any
+func (s *Server) injectFaults(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fault, ok := s.takeFault(r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if fault.Delay > 0 {
+			select {
+			case <-time.After(fault.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		switch {
+		case fault.Captcha:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, captchaPage)
+		case fault.Empty:
+			w.WriteHeader(http.StatusOK)
+		case fault.StatusCode != 0:
+			w.WriteHeader(fault.StatusCode)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// takeFault returns the fault for path, using up one of its Times
+func (s *Server) takeFault(path string) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range []string{path, "*"} {
+		fault, ok := s.faults[key]
+		if !ok {
+			continue
+		}
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				delete(s.faults, key)
+			}
+		}
+		return *fault, true
+	}
+	return Fault{}, false
+}
+
+// handleShortLink redirects a short link to its target
+func (s *Server) handleShortLink(w http.ResponseWriter, r *http.Request) {
+	code := strings.Trim(strings.TrimPrefix(r.URL.Path, PathShortLink), "/")
+
+	s.mu.Lock()
+	target, ok := s.links[code]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+}
+
+// handleMusicDetail serves api/music/detail
+func (s *Server) handleMusicDetail(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	fixture, ok := s.sounds[r.URL.Query().Get("musicId")]
+	s.mu.Unlock()
+
+	if !ok || fixture.Info == nil {
+		writeJSON(w, notFound())
+		return
+	}
+
+	writeJSON(w, fixture.Info)
+}
+
+// handleMusicItemList serves api/music/item_list with cursor paging
+func (s *Server) handleMusicItemList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	fixture, ok := s.sounds[query.Get("musicID")]
+	pageSize := s.pageSize
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, notFound())
+		return
+	}
+
+	writeJSON(w, listPage(query, pageSize, "itemList", fixture.Videos))
+}
+
+// handleMixDetail serves api/mix/detail
+func (s *Server) handleMixDetail(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	fixture, ok := s.playlists[r.URL.Query().Get("mixId")]
+	s.mu.Unlock()
+
+	if !ok || fixture.Info == nil {
+		writeJSON(w, notFound())
+		return
+	}
+
+	writeJSON(w, fixture.Info)
+}
+
+// handleMixItemList serves api/mix/item_list with cursor paging
+func (s *Server) handleMixItemList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	fixture, ok := s.playlists[query.Get("mixId")]
+	pageSize := s.pageSize
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, notFound())
+		return
+	}
+
+	writeJSON(w, listPage(query, pageSize, "itemList", fixture.Videos))
+}
+
+// handleUserDetail serves api/user/detail, looking the user up by uniqueId or secUid
+func (s *Server) handleUserDetail(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	fixture, ok := s.findUser(query.Get("uniqueId"), query.Get("secUid"))
+	s.mu.Unlock()
+
+	if !ok || fixture.Info == nil {
+		writeJSON(w, notFound())
+		return
+	}
+
+	writeJSON(w, fixture.Info)
+}
+
+// handleUserPlaylist serves api/user/playlist with cursor paging
+func (s *Server) handleUserPlaylist(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	fixture, ok := s.findUser("", query.Get("secUid"))
+	pageSize := s.pageSize
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, notFound())
+		return
+	}
+
+	writeJSON(w, listPage(query, pageSize, "playList", fixture.Playlists))
+}
+
+// findUser returns the user with the given username or secUid, the caller must hold s.mu
+func (s *Server) findUser(username string, secUID string) (UserFixture, bool) {
+	if fixture, ok := s.users[username]; ok && username != "" {
+		return fixture, true
+	}
+	if secUID == "" {
+		return UserFixture{}, false
+	}
+	for _, fixture := range s.users {
+		userInfo, _ := fixture.Info["userInfo"].(map[string]interface{})
+		user, _ := userInfo["user"].(map[string]interface{})
+		if user["secUid"] == secUID {
+			return fixture, true
+		}
+	}
+	return UserFixture{}, false
+}
+
+// handleTrending serves api/recommend/item_list, one page further into the feed per request
+func (s *Server) handleTrending(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	pageSize := s.pageSize
+	if count, err := strconv.Atoi(r.URL.Query().Get("count")); err == nil && count > 0 && count < pageSize {
+		pageSize = count
+	}
+	start := min(s.feedPos, len(s.trending))
+	end := min(start+pageSize, len(s.trending))
+	s.feedPos = end
+	items := make([]interface{}, 0, end-start)
+	for _, video := range s.trending[start:end] {
+		items = append(items, video)
+	}
+	hasMore := end < len(s.trending)
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"statusCode": 0,
+		"itemList":   items,
+		"hasMore":    hasMore,
+	})
+}
+
+// listPage returns the page of items selected by the cursor and count query
+// parameters, under key. TikTok sends the cursor as a string.
+func listPage(query url.Values, pageSize int, key string, all []map[string]interface{}) map[string]interface{} {
+	cursor, _ := strconv.Atoi(query.Get("cursor"))
+	if count, err := strconv.Atoi(query.Get("count")); err == nil && count > 0 && count < pageSize {
+		pageSize = count
+	}
+
+	// Slice out the page
+	start := min(max(cursor, 0), len(all))
+	end := min(start+pageSize, len(all))
+	items := make([]interface{}, 0, end-start)
+	for _, item := range all[start:end] {
+		items = append(items, item)
+	}
+
+	return map[string]interface{}{
+		"statusCode": 0,
+		key:          items,
+		"hasMore":    end < len(all),
+		"cursor":     strconv.Itoa(end),
+	}
+}
+
+// musicPagePathRe matches /music/<slug>-<id> page paths
+var musicPagePathRe = regexp.MustCompile(`^/music/(?:.*-)?(\d+)/?$`)
+
+// handleMusicPage serves the server-rendered music page with embedded rehydration data
+func (s *Server) handleMusicPage(w http.ResponseWriter, r *http.Request) {
+	m := musicPagePathRe.FindStringSubmatch(r.URL.Path)
+	if m == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	fixture, ok := s.sounds[m[1]]
+	s.mu.Unlock()
+
+	scope := notFound()
+	if ok && fixture.Info != nil {
+		scope = map[string]interface{}{
+			"statusCode": 0,
+			"musicInfo":  fixture.Info["musicInfo"],
+			"shareMeta":  fixture.Info["shareMeta"],
+		}
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"__DEFAULT_SCOPE__": map[string]interface{}{
+			"webapp.music-detail": scope,
+		},
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!DOCTYPE html><html><head></head><body><script id="__UNIVERSAL_DATA_FOR_REHYDRATION__" type="application/json">%s</script></body></html>`, data)
+}
+
+// notFound returns the body TikTok sends for unknown entities
+func notFound() map[string]interface{} {
+	return map[string]interface{}{
+		"statusCode":  StatusNotFound,
+		"status_code": StatusNotFound,
+		"status_msg":  "item not found",
+	}
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// captchaPage is served in place of JSON when a captcha fault is injected
+const captchaPage = `<!DOCTYPE html>
+<html><head><title>Security Check</title></head>
+<body><div id="captcha-verify-container">Please complete the security check to continue.</div></body>
+</html>`
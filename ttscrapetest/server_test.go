@@ -0,0 +1,181 @@
+package ttscrapetest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ttscrape_go "github.com/fortindustries/ttscrape-go"
+	"github.com/fortindustries/ttscrape-go/ttscrapetest"
+)
+
+// newAPI starts a server serving sound 1 with videos videos and returns a
+// single-session API using it
+func newAPI(t *testing.T, videos int) (*ttscrape_go.TikTokAPI, *ttscrapetest.Server) {
+	t.Helper()
+	srv := ttscrapetest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddSound("1", ttscrapetest.SyntheticFixture("1", videos))
+
+	api, err := srv.NewAPI(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { api.Close() })
+	return api, srv
+}
+
+func TestPaging(t *testing.T) {
+	api, srv := newAPI(t, 45)
+	srv.SetPageSize(20)
+
+	videos, err := api.Sound("1").Videos(context.Background(), 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[interface{}]bool{}
+	for video := range videos {
+		seen[video["id"]] = true
+	}
+	if len(seen) != 45 {
+		t.Errorf("got %d distinct videos, want 45", len(seen))
+	}
+	if n := srv.Requests(ttscrapetest.PathMusicItemList); n != 3 {
+		t.Errorf("%d item_list requests, want 3", n)
+	}
+}
+
+func TestUnknownSound(t *testing.T) {
+	api, _ := newAPI(t, 1)
+
+	info, err := api.Sound("2").Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statusErr *ttscrape_go.StatusError
+	if err := ttscrape_go.CheckStatus(info); !errors.As(err, &statusErr) || statusErr.Code != ttscrapetest.StatusNotFound {
+		t.Errorf("CheckStatus = %v, want statusCode %d", err, ttscrapetest.StatusNotFound)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	api, srv := newAPI(t, 1)
+	ctx := context.Background()
+	sound := api.Sound("1")
+
+	// A fault with Times affects only that many requests
+	srv.SetFault(ttscrapetest.PathMusicDetail, ttscrapetest.Fault{StatusCode: http.StatusInternalServerError, Times: 1})
+	var httpErr *ttscrape_go.HTTPStatusError
+	if _, err := sound.Info(ctx); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Info with a 500 fault returned %v", err)
+	}
+	if _, err := sound.Info(ctx, ttscrape_go.WithCachePolicy(ttscrape_go.CacheBypass)); err != nil {
+		t.Errorf("Info once the fault was used up: %v", err)
+	}
+
+	// "*" affects every path until cleared
+	srv.SetFault("*", ttscrapetest.Fault{Captcha: true})
+	if _, err := sound.Info(ctx, ttscrape_go.WithCachePolicy(ttscrape_go.CacheBypass)); err == nil {
+		t.Error("Info with a captcha fault succeeded")
+	}
+	srv.ClearFaults()
+	if _, err := sound.Info(ctx, ttscrape_go.WithCachePolicy(ttscrape_go.CacheBypass)); err != nil {
+		t.Errorf("Info after ClearFaults: %v", err)
+	}
+}
+
+func TestMusicPage(t *testing.T) {
+	api, srv := newAPI(t, 1)
+	srv.SetFault(ttscrapetest.PathMusicDetail, ttscrapetest.Fault{Empty: true})
+
+	info, err := api.Sound("1").Info(context.Background(), ttscrape_go.WithPageFallback(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info["musicInfo"] == nil {
+		t.Errorf("page fallback returned %v", info)
+	}
+}
+
+func TestReadResultsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	results := `{"results": {
+		"1": {"info": {"statusCode": 0, "musicInfo": {}}, "videos": [{"id": "10"}, {"id": "11"}]},
+		"2": {"error": "captcha"}
+	}}`
+	if err := os.WriteFile(path, []byte(results), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := ttscrapetest.NewServer()
+	defer srv.Close()
+	ids, err := srv.LoadResultsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != "1" {
+		t.Errorf("loaded %v, want only sound 1", ids)
+	}
+
+	fixtures, err := ttscrapetest.ReadResultsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures["1"].Videos) != 2 {
+		t.Errorf("sound 1 has %d videos, want 2", len(fixtures["1"].Videos))
+	}
+}
+
+func TestSyntheticFixture(t *testing.T) {
+	fixture := ttscrapetest.SyntheticFixture("5", 3)
+	if len(fixture.Videos) != 3 || fixture.Info["statusCode"] != 0 {
+		t.Fatalf("fixture has %d videos and statusCode %v", len(fixture.Videos), fixture.Info["statusCode"])
+	}
+	ids := map[interface{}]bool{}
+	for _, video := range fixture.Videos {
+		ids[video["id"]] = true
+		if music, _ := video["music"].(map[string]interface{}); music["id"] != "5" {
+			t.Errorf("video %v uses sound %v", video["id"], music["id"])
+		}
+	}
+	if len(ids) != 3 {
+		t.Errorf("video IDs are not distinct: %v", ids)
+	}
+}
+
+func TestUserLookup(t *testing.T) {
+	api, srv := newAPI(t, 1)
+	srv.AddUser("someone", ttscrapetest.SyntheticUser("someone", 0))
+
+	// Users are found by username or by secUid
+	for _, user := range []*ttscrape_go.User{api.User("someone"), {API: api, SecUID: "MS4wLjABAAAAsomeone"}} {
+		info, err := user.Info(context.Background())
+		if err == nil {
+			err = ttscrape_go.CheckStatus(info)
+		}
+		if err != nil || user.Username != "someone" {
+			t.Errorf("Info of %+v: %v, username %q", user, err, user.Username)
+		}
+	}
+}
+
+func TestTrendingFeed(t *testing.T) {
+	api, srv := newAPI(t, 1)
+	srv.SetPageSize(4)
+	srv.SetTrending(ttscrapetest.SyntheticFixture("1", 6).Videos)
+
+	// Each request moves on through the feed until it runs out
+	for _, want := range []int{4, 2, 0} {
+		resp, err := api.MakeRequest(ttscrapetest.PathTrending, nil, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items, _ := resp["itemList"].([]any)
+		if hasMore, _ := resp["hasMore"].(bool); len(items) != want || hasMore != (want == 4) {
+			t.Errorf("got %d items, hasMore %v, want %d items", len(items), hasMore, want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package ttscrape_go
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// User represents a TikTok user/creator
+type User struct {
+	API      *TikTokAPI // Reference to the TikTokAPI
+	Username string
+	UserID   string
+	SecUID   string
+	Nickname string
+	AsDict   map[string]interface{}
+	mu       sync.Mutex
+}
+
+// Info retrieves information about the user
+func (u *User) Info(ctx context.Context, options ...RequestOption) (map[string]interface{}, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.info(ctx, options...)
+}
+
+// info retrieves information about the user, the caller must hold u.mu
+func (u *User) info(ctx context.Context, options ...RequestOption) (map[string]interface{}, error) {
+	// Get the API reference
+	api := u.API
+	if api == nil {
+		return nil, errInvalidAPI
+	}
+
+	if u.Username == "" && u.SecUID == "" {
+		return nil, fmt.Errorf("user needs a username or secUid")
+	}
+
+	opts := newRequestOptions(options)
+
+	// Set up URL parameters
+	params := map[string]string{
+		"uniqueId": u.Username,
+		"secUid":   u.SecUID,
+	}
+
+	// Make the request
+	resp, err := api.makeRequest(ctx, opts.call("/api/user/detail/", params))
+
+	// Fall back to the profile page if the endpoint is blocked, which needs a username
+	if u.Username != "" {
+		resp, err = withPageFallback(ctx, u.API, opts, resp, err, "userInfo",
+			"/@"+url.PathEscape(u.Username), scopeUserDetail)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if response is valid
+	if resp == nil {
+		return nil, errInvalidResponse
+	}
+
+	// Extract data
+	u.AsDict = resp
+	u.extractFromData()
+
+	return resp, nil
+}
+
+// Playlists retrieves the playlists created by the user
+func (u *User) Playlists(ctx context.Context, count int, cursor int, options ...RequestOption) (chan *Playlist, error) {
+	// Get the API reference
+	api := u.API
+	if api == nil {
+		return nil, errInvalidAPI
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive")
+	}
+
+	// The playlist endpoint only accepts a secUid, so look it up first if needed
+	u.mu.Lock()
+	if u.SecUID == "" {
+		resp, err := u.info(ctx, options...)
+		if err == nil {
+			err = CheckStatus(resp) // An unknown user has no ID
+		}
+		if err != nil {
+			u.mu.Unlock()
+			return nil, err
+		}
+	}
+	secUID := u.SecUID
+	u.mu.Unlock()
+
+	if secUID == "" {
+		return nil, fmt.Errorf("could not determine secUid for user %s", u.Username)
+	}
+
+	opts := newRequestOptions(options)
+
+	// Create a channel to send playlists
+	playlists := make(chan *Playlist, count)
+
+	// Register the goroutine so Shutdown can stop it and wait for it
+	stop, done, err := api.beginPaging()
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a goroutine to fetch playlists
+	go func() {
+		defer done()
+		defer close(playlists)
+
+		end := pagingEnd{Cursor: cursor, HasMore: true}
+		defer opts.pagingEnded(&end)
+
+		currentCount := 0
+		currentCursor := cursor
+		defer func() {
+			api.observePaging("user_playlists", currentCount)
+		}()
+
+		for currentCount < count {
+			// Set up URL parameters
+			params := map[string]string{
+				"secUid": secUID,
+				"count":  fmt.Sprintf("%d", 20), // Max count per request
+				"cursor": fmt.Sprintf("%d", currentCursor),
+			}
+
+			// Make the request
+			resp, err := api.makeRequest(ctx, opts.call("/api/user/playlist/", params))
+			if err := pageError(resp, err); err != nil {
+				opts.pagingFailed(err)
+				return
+			}
+
+			// Extract playlists
+			playList, ok := resp["playList"].([]interface{})
+			api.logger().Debug("page fetched", "entity", "user", "cursor", currentCursor, "items", len(playList))
+			if !ok || len(playList) == 0 {
+				end.HasMore = false
+				return
+			}
+
+			// Send playlists to channel
+			for i, item := range playList {
+				if currentCount >= count {
+					return
+				}
+
+				playlistMap, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				playlist := &Playlist{
+					API:    u.API,
+					AsDict: playlistMap,
+				}
+				playlist.extractFromData()
+
+				select {
+				case playlists <- playlist:
+				case <-stop:
+					opts.pagingFailed(ErrShutdown)
+					return
+				case <-ctx.Done():
+					opts.pagingFailed(ctx.Err())
+					return
+				}
+				currentCount++
+				end.Cursor = currentCursor + i + 1
+			}
+
+			// Update cursor for next page
+			hasMore, ok := resp["hasMore"].(bool)
+			if !ok || !hasMore {
+				end.HasMore = false
+				return
+			}
+
+			nextCursor, ok := cursorFromResponse(resp)
+			if !ok {
+				end.HasMore = false
+				return
+			}
+
+			currentCursor = nextCursor
+			end.Cursor = nextCursor
+		}
+	}()
+
+	return playlists, nil
+}
+
+// extractFromData extracts data from the API response
+func (u *User) extractFromData() {
+	if u.AsDict == nil {
+		return
+	}
+
+	// Extract user info
+	userInfo, ok := u.AsDict["userInfo"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	user, ok := userInfo["user"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	// Extract identifiers
+	if id, ok := user["id"].(string); ok {
+		u.UserID = id
+	}
+	if secUID, ok := user["secUid"].(string); ok {
+		u.SecUID = secUID
+	}
+	if uniqueID, ok := user["uniqueId"].(string); ok {
+		u.Username = uniqueID
+	}
+
+	// Extract nickname
+	if nickname, ok := user["nickname"].(string); ok {
+		u.Nickname = nickname
+	}
+}
@@ -0,0 +1,125 @@
+package ttscrape_go
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Video represents a TikTok video
+type Video struct {
+	API            *TikTokAPI // Reference to the TikTokAPI
+	ID             string
+	Description    string
+	CreateTime     int64
+	AuthorUsername string
+	SoundID        string
+	AsDict         map[string]interface{}
+	mu             sync.Mutex
+}
+
+// Info retrieves information about the video
+func (v *Video) Info(ctx context.Context, options ...RequestOption) (map[string]interface{}, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Get the API reference
+	api := v.API
+	if api == nil {
+		return nil, errInvalidAPI
+	}
+
+	opts := newRequestOptions(options)
+
+	// Set up URL parameters
+	params := map[string]string{
+		"itemId": v.ID,
+	}
+
+	// Make the request
+	resp, err := api.makeRequest(ctx, opts.call("/api/item/detail/", params))
+
+	// Fall back to the video page if the endpoint is blocked
+	author := v.AuthorUsername
+	if author == "" {
+		author = "_"
+	}
+	resp, err = withPageFallback(ctx, v.API, opts, resp, err, "itemInfo",
+		"/@"+author+"/video/"+v.ID, scopeVideoDetail)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if response is valid
+	if resp == nil {
+		return nil, errInvalidResponse
+	}
+
+	// Extract the item
+	itemInfo, ok := resp["itemInfo"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("TikTok returned a response without itemInfo")
+	}
+	item, ok := itemInfo["itemStruct"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("TikTok returned a response without itemStruct")
+	}
+
+	v.AsDict = item
+	v.extractFromData()
+
+	return resp, nil
+}
+
+// Sound returns the sound used in the video, if known
+func (v *Video) Sound() *Sound {
+	if v.SoundID == "" {
+		return nil
+	}
+	return &Sound{
+		API: v.API,
+		ID:  v.SoundID,
+	}
+}
+
+// extractFromData extracts data from the item struct
+func (v *Video) extractFromData() {
+	if v.AsDict == nil {
+		return
+	}
+
+	// Extract ID
+	if id, ok := v.AsDict["id"].(string); ok {
+		v.ID = id
+	}
+
+	// Extract description
+	if desc, ok := v.AsDict["desc"].(string); ok {
+		v.Description = desc
+	}
+
+	// Extract create time, which TikTok sends either as a number or a string
+	switch createTime := v.AsDict["createTime"].(type) {
+	case float64:
+		v.CreateTime = int64(createTime)
+	case string:
+		if parsed, err := strconv.ParseInt(createTime, 10, 64); err == nil {
+			v.CreateTime = parsed
+		}
+	}
+
+	// Extract author
+	if author, ok := v.AsDict["author"].(map[string]interface{}); ok {
+		if uniqueID, ok := author["uniqueId"].(string); ok {
+			v.AuthorUsername = uniqueID
+		}
+	}
+
+	// Extract sound
+	if music, ok := v.AsDict["music"].(map[string]interface{}); ok {
+		if id, ok := music["id"].(string); ok {
+			v.SoundID = id
+		}
+	}
+}